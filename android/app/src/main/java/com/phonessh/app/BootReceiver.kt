@@ -3,6 +3,7 @@ package com.phonessh.app
 import android.content.BroadcastReceiver
 import android.content.Context
 import android.content.Intent
+import com.phonessh.app.commands.ScheduledSms
 
 class BootReceiver : BroadcastReceiver() {
     override fun onReceive(context: Context, intent: Intent) {
@@ -10,6 +11,7 @@ class BootReceiver : BroadcastReceiver() {
             intent.action == Intent.ACTION_MY_PACKAGE_REPLACED) {
             val serviceIntent = Intent(context, PhoneSSHService::class.java)
             context.startForegroundService(serviceIntent)
+            ScheduledSms.rearm(context)
         }
     }
 }
@@ -19,6 +19,7 @@ import androidx.core.content.ContextCompat
 import com.google.zxing.BarcodeFormat
 import com.journeyapps.barcodescanner.BarcodeEncoder
 import com.phonessh.app.auth.KeyAuthManager
+import com.phonessh.app.commands.AppUsage
 import com.phonessh.app.databinding.ActivityMainBinding
 import java.net.Inet4Address
 import java.net.NetworkInterface
@@ -133,6 +134,10 @@ class MainActivity : AppCompatActivity() {
         binding.btnAccessibilityAccess.setOnClickListener {
             startActivity(Intent(Settings.ACTION_ACCESSIBILITY_SETTINGS))
         }
+
+        binding.btnUsageAccess.setOnClickListener {
+            startActivity(Intent(Settings.ACTION_USAGE_ACCESS_SETTINGS))
+        }
     }
 
     private fun startService() {
@@ -192,6 +197,9 @@ class MainActivity : AppCompatActivity() {
 
         binding.tvAccessibilityStatus.text = if (PshAccessibilityService.instance != null)
             "Accessibility (screenshot): granted" else "Accessibility (screenshot): not granted"
+
+        binding.tvUsageAccessStatus.text = if (AppUsage.hasAccess(this))
+            "Usage access: granted" else "Usage access: not granted"
     }
 
     private fun addLogEntry(text: String) {
@@ -221,6 +229,10 @@ class MainActivity : AppCompatActivity() {
             Manifest.permission.READ_SMS,
             Manifest.permission.SEND_SMS,
             Manifest.permission.READ_CONTACTS,
+            Manifest.permission.READ_CALL_LOG,
+            Manifest.permission.READ_PHONE_STATE,
+            Manifest.permission.CALL_PHONE,
+            Manifest.permission.ANSWER_PHONE_CALLS,
             Manifest.permission.ACCESS_FINE_LOCATION,
             Manifest.permission.POST_NOTIFICATIONS
         )
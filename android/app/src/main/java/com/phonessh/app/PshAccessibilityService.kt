@@ -2,11 +2,14 @@ package com.phonessh.app
 
 import android.accessibilityservice.AccessibilityService
 import android.accessibilityservice.GestureDescription
+import android.content.ComponentName
+import android.content.pm.PackageManager
 import android.graphics.Bitmap
 import android.graphics.Path
 import android.os.Bundle
 import android.os.Handler
 import android.os.Looper
+import android.provider.Settings
 import android.view.Display
 import android.view.accessibility.AccessibilityEvent
 import android.view.accessibility.AccessibilityNodeInfo
@@ -18,6 +21,9 @@ import java.util.concurrent.atomic.AtomicReference
 
 class PshAccessibilityService : AccessibilityService() {
 
+    /** One foreground app/activity change seen by the service. */
+    data class ForegroundChange(val seq: Long, val time: Long, val pkg: String, val activity: String?)
+
     companion object {
         @Volatile
         var instance: PshAccessibilityService? = null
@@ -209,6 +215,82 @@ class PshAccessibilityService : AccessibilityService() {
             }
         }
 
+        /**
+         * Dump every node in the active window, in document order. Each node
+         * carries the index of its parent (-1 for the root) so the client can
+         * rebuild the hierarchy for selector and XPath matching.
+         */
+        fun dumpTree(): List<Map<String, Any>> {
+            val svc = instance ?: return emptyList()
+            val root = svc.rootInActiveWindow ?: return emptyList()
+            val results = mutableListOf<Map<String, Any>>()
+            collectTree(root, -1, 0, results)
+            root.recycle()
+            return results
+        }
+
+        private fun collectTree(node: AccessibilityNodeInfo, parent: Int, depth: Int, out: MutableList<Map<String, Any>>) {
+            val rect = android.graphics.Rect()
+            node.getBoundsInScreen(rect)
+            val index = out.size
+            out.add(mapOf(
+                "index"      to index,
+                "parent"     to parent,
+                "depth"      to depth,
+                "class"      to (node.className?.toString() ?: ""),
+                "package"    to (node.packageName?.toString() ?: ""),
+                "id"         to (node.viewIdResourceName ?: ""),
+                "text"       to (node.text?.toString() ?: ""),
+                "desc"       to (node.contentDescription?.toString() ?: ""),
+                "hint"       to (node.hintText?.toString() ?: ""),
+                "clickable"  to node.isClickable,
+                "enabled"    to node.isEnabled,
+                "focused"    to node.isFocused,
+                "checkable"  to node.isCheckable,
+                "checked"    to node.isChecked,
+                "selected"   to node.isSelected,
+                "scrollable" to node.isScrollable,
+                "editable"   to node.isEditable,
+                "visible"    to node.isVisibleToUser,
+                "bounds"     to "${rect.left},${rect.top},${rect.right},${rect.bottom}"
+            ))
+
+            for (i in 0 until node.childCount) {
+                val child = node.getChild(i) ?: continue
+                collectTree(child, index, depth + 1, out)
+                child.recycle()
+            }
+        }
+
+        private const val MAX_CHANGES = 500
+        private val changes = ArrayDeque<ForegroundChange>()
+        private var lastSeq = 0L
+
+        /** The app (and activity, when known) currently in the foreground. */
+        fun currentForeground(): ForegroundChange? {
+            synchronized(changes) { changes.lastOrNull()?.let { return it } }
+            // Nothing recorded since the service started: ask the active window
+            val root = instance?.rootInActiveWindow ?: return null
+            val pkg = root.packageName?.toString()
+            root.recycle()
+            return pkg?.let { ForegroundChange(0, System.currentTimeMillis(), it, null) }
+        }
+
+        /** Changes with a sequence number above [after], oldest first. */
+        fun foregroundChangesAfter(after: Long): Pair<Long, List<ForegroundChange>> =
+            synchronized(changes) { Pair(lastSeq, changes.filter { it.seq > after }) }
+
+        private fun recordForeground(pkg: String, activity: String?) {
+            synchronized(changes) {
+                val last = changes.lastOrNull()
+                if (last != null && last.pkg == pkg && (activity == null || activity == last.activity)) return
+                // A dialog or popup inside the same app keeps the known activity
+                val act = activity ?: last?.activity?.takeIf { last.pkg == pkg }
+                changes.addLast(ForegroundChange(++lastSeq, System.currentTimeMillis(), pkg, act))
+                while (changes.size > MAX_CHANGES) changes.removeFirst()
+            }
+        }
+
         /**
          * Press a navigation/hardware key.
          * Valid actions: back, home, recents, notifications
@@ -236,6 +318,22 @@ class PshAccessibilityService : AccessibilityService() {
         return super.onUnbind(intent)
     }
 
-    override fun onAccessibilityEvent(event: AccessibilityEvent?) {}
+    override fun onAccessibilityEvent(event: AccessibilityEvent?) {
+        if (event?.eventType != AccessibilityEvent.TYPE_WINDOW_STATE_CHANGED) return
+        val pkg = event.packageName?.toString() ?: return
+        // The keyboard popping up is not an app switch
+        val ime = Settings.Secure.getString(contentResolver, Settings.Secure.DEFAULT_INPUT_METHOD)
+        if (ime != null && ime.substringBefore('/') == pkg) return
+
+        val activity = event.className?.toString()?.takeIf { cls ->
+            try {
+                packageManager.getActivityInfo(ComponentName(pkg, cls), 0)
+                true
+            } catch (e: PackageManager.NameNotFoundException) {
+                false
+            }
+        }
+        recordForeground(pkg, activity)
+    }
     override fun onInterrupt() {}
 }
@@ -0,0 +1,11 @@
+package com.phonessh.app
+
+import android.app.admin.DeviceAdminReceiver
+
+/**
+ * Device admin component. Nothing is enforced through it; when PhoneSSH is made
+ * device owner on a test phone (adb shell dpm set-device-owner
+ * com.phonessh.app/.PshDeviceAdminReceiver) it unlocks privileged app controls
+ * such as granting permissions without the settings UI.
+ */
+class PshDeviceAdminReceiver : DeviceAdminReceiver()
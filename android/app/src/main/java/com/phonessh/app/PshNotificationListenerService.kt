@@ -1,5 +1,6 @@
 package com.phonessh.app
 
+import android.content.Context
 import android.service.notification.NotificationListenerService
 import android.service.notification.StatusBarNotification
 import java.util.concurrent.CopyOnWriteArrayList
@@ -26,10 +27,14 @@ class PshNotificationListenerService : NotificationListenerService() {
             private set
 
         private const val MAX_STORED = 200
+        private const val MUTE_PREFS = "psh_muted_apps"
     }
 
     private val notifications = CopyOnWriteArrayList<CapturedNotification>()
 
+    /** Muted app filters → muted-until epoch millis (0 = until unmuted). Survives restarts. */
+    private val mutePrefs by lazy { getSharedPreferences(MUTE_PREFS, Context.MODE_PRIVATE) }
+
     override fun onCreate() {
         super.onCreate()
         instance = this
@@ -41,6 +46,12 @@ class PshNotificationListenerService : NotificationListenerService() {
     }
 
     override fun onNotificationPosted(sbn: StatusBarNotification) {
+        // Suppress notifications from muted apps
+        if (isMuted(sbn.packageName) && !sbn.isOngoing) {
+            runCatching { cancelNotification(sbn.key) }
+            return
+        }
+
         val extras = sbn.notification.extras
         val n = CapturedNotification(
             key      = sbn.key,
@@ -87,6 +98,56 @@ class PshNotificationListenerService : NotificationListenerService() {
         runCatching { cancelAllNotifications() }
         notifications.clear()
     }
+
+    /** Snooze a single notification by key. Returns false if the key is unknown. */
+    fun snooze(key: String, durationMs: Long): Boolean {
+        if (notifications.none { it.key == key }) return false
+        snoozeNotification(key, durationMs)
+        notifications.removeIf { it.key == key }
+        return true
+    }
+
+    /** Snooze every non-ongoing notification from matching packages. Returns count snoozed. */
+    fun snoozeApp(pkg: String, durationMs: Long): Int {
+        val toSnooze = notifications.filter { it.pkg.contains(pkg, ignoreCase = true) && !it.ongoing }
+        toSnooze.forEach { n ->
+            runCatching { snoozeNotification(n.key, durationMs) }
+            notifications.removeIf { it.key == n.key }
+        }
+        return toSnooze.size
+    }
+
+    /**
+     * Mute an app: its new notifications are cancelled as they arrive.
+     * [untilMs] is an epoch time in millis, or 0 to mute until [unmute] is called.
+     */
+    fun mute(app: String, untilMs: Long) {
+        mutePrefs.edit().putLong(app.lowercase(), untilMs).apply()
+    }
+
+    /** Returns false if the app was not muted. */
+    fun unmute(app: String): Boolean {
+        val key = app.lowercase()
+        if (!mutePrefs.contains(key)) return false
+        mutePrefs.edit().remove(key).apply()
+        return true
+    }
+
+    /** Currently muted app filters → muted-until millis (0 = indefinitely). Expired entries are dropped. */
+    fun mutedApps(): Map<String, Long> {
+        val now = System.currentTimeMillis()
+        val editor = mutePrefs.edit()
+        val active = mutableMapOf<String, Long>()
+        mutePrefs.all.forEach { (app, v) ->
+            val until = v as? Long ?: return@forEach
+            if (until != 0L && until <= now) editor.remove(app) else active[app] = until
+        }
+        editor.apply()
+        return active
+    }
+
+    private fun isMuted(pkg: String): Boolean =
+        mutedApps().keys.any { pkg.contains(it, ignoreCase = true) }
 }
 
 private fun <T> CopyOnWriteArrayList<T>.removeLastOrNull(): T? {
@@ -1,25 +1,39 @@
 package com.phonessh.app.commands
 
+import android.app.admin.DevicePolicyManager
 import android.content.ActivityNotFoundException
 import android.content.Context
 import android.content.Intent
 import android.content.pm.ApplicationInfo
+import android.content.pm.PackageInfo
 import android.content.pm.PackageManager
+import android.content.pm.PermissionInfo
 import android.net.Uri
+import com.phonessh.app.PshAccessibilityService
 import com.phonessh.app.protocol.CmdMsg
 import com.phonessh.app.protocol.resultErr
 import com.phonessh.app.protocol.resultOk
 import java.io.File
+import java.util.concurrent.CountDownLatch
+import java.util.concurrent.TimeUnit
 
 class AppCommands(private val context: Context) {
 
     /**
-     * psh apps list [--system]
+     * psh apps list [--system] [--details]   (--details adds version and install times)
      * psh apps launch <name-or-package>
      * psh apps kill <name-or-package>
      * psh apps info <name-or-package>
      * psh apps install <local-apk-path>
+     * psh apps install-session --dir <staging-dir>   (every .apk in dir, as one split set)
+     * psh apps install-status <session-id>
      * psh apps uninstall <package>
+     * psh apps perms <name-or-package> [grant|revoke <permission>]
+     * psh apps perms --who-has <permission> [--system]
+     * psh apps usage [--since <epoch-ms>]
+     * psh apps current
+     * psh apps foreground [--after <seq>]   (foreground changes after seq; without it, just the latest seq)
+     * psh apps stop|clear-cache|clear-data|disable|enable <name-or-package>
      */
     fun dispatch(cmd: CmdMsg): String {
         val subCmd = cmd.args.firstOrNull() ?: return resultErr(cmd.id, "usage: apps [list|launch|kill|info|install|uninstall]")
@@ -29,7 +43,14 @@ class AppCommands(private val context: Context) {
             "kill"      -> kill(cmd)
             "info"      -> info(cmd)
             "install"   -> install(cmd)
+            "install-session" -> installSession(cmd)
+            "install-status"  -> installStatus(cmd)
             "uninstall" -> uninstall(cmd)
+            "perms"     -> perms(cmd)
+            "usage"     -> usage(cmd)
+            "current"   -> current(cmd)
+            "foreground" -> foreground(cmd)
+            "stop", "clear-cache", "clear-data", "disable", "enable" -> control(cmd, subCmd)
             else        -> resultErr(cmd.id, "unknown apps subcommand: $subCmd")
         }
     }
@@ -38,6 +59,7 @@ class AppCommands(private val context: Context) {
         val pm = context.packageManager
         val includeSystem = cmd.flags.containsKey("system")
         val filter = cmd.flags["filter"] ?: cmd.args.getOrNull(1)
+        val details = cmd.flags.containsKey("details")
 
         val apps = pm.getInstalledApplications(PackageManager.GET_META_DATA)
             .filter { app ->
@@ -50,12 +72,20 @@ class AppCommands(private val context: Context) {
             }
             .sortedBy { pm.getApplicationLabel(it).toString().lowercase() }
             .map { app ->
-                mapOf(
+                val entry = mutableMapOf<String, Any?>(
                     "name"    to pm.getApplicationLabel(app).toString(),
                     "package" to app.packageName,
                     "system"  to ((app.flags and ApplicationInfo.FLAG_SYSTEM) != 0),
                     "enabled" to app.enabled
                 )
+                if (details) {
+                    val pkgInfo = pm.getPackageInfo(app.packageName, 0)
+                    entry["version_name"] = pkgInfo.versionName
+                    entry["version_code"] = pkgInfo.longVersionCode
+                    entry["installed"] = pkgInfo.firstInstallTime
+                    entry["updated"] = pkgInfo.lastUpdateTime
+                }
+                entry
             }
 
         return resultOk(cmd.id, mapOf("count" to apps.size, "apps" to apps))
@@ -113,6 +143,7 @@ class AppCommands(private val context: Context) {
             "system"       to ((appInfo.flags and ApplicationInfo.FLAG_SYSTEM) != 0),
             "enabled"      to appInfo.enabled,
             "apk_path"     to appInfo.sourceDir,
+            "split_apks"   to (appInfo.splitSourceDirs?.toList() ?: emptyList()),
             "data_dir"     to appInfo.dataDir
         ))
     }
@@ -141,6 +172,26 @@ class AppCommands(private val context: Context) {
         }
     }
 
+    private fun installSession(cmd: CmdMsg): String {
+        val dir = cmd.flags["dir"]?.let { File(it) }
+            ?: return resultErr(cmd.id, "usage: apps install-session --dir <staging-dir>")
+        if (!dir.isDirectory) return resultErr(cmd.id, "not a directory: ${dir.path}")
+
+        return try {
+            // Wait well inside the CLI's command timeout; it polls install-status after that
+            val sessionId = AppInstaller.install(context, dir, waitMs = 40_000)
+            resultOk(cmd.id, AppInstaller.status(context, sessionId))
+        } catch (e: Exception) {
+            resultErr(cmd.id, "install failed: ${e.message}")
+        }
+    }
+
+    private fun installStatus(cmd: CmdMsg): String {
+        val sessionId = cmd.args.getOrNull(1)?.toIntOrNull()
+            ?: return resultErr(cmd.id, "usage: apps install-status <session-id>")
+        return resultOk(cmd.id, AppInstaller.status(context, sessionId))
+    }
+
     private fun uninstall(cmd: CmdMsg): String {
         val query = cmd.args.getOrNull(1) ?: return resultErr(cmd.id, "usage: apps uninstall <package>")
         val pkg = resolvePackage(query) ?: query  // try as literal package name
@@ -161,6 +212,205 @@ class AppCommands(private val context: Context) {
         }
     }
 
+    private fun perms(cmd: CmdMsg): String {
+        cmd.flags["who-has"]?.let { return whoHas(cmd, permissionName(it)) }
+
+        val usage = "usage: apps perms <name-or-package> [grant|revoke <permission>] | apps perms --who-has <permission>"
+        val query = cmd.args.getOrNull(1) ?: return resultErr(cmd.id, usage)
+        val pkg = resolvePackage(query) ?: return resultErr(cmd.id, "app not found: $query")
+        when (val action = cmd.args.getOrNull(2)) {
+            null -> {}
+            "grant", "revoke" -> {
+                val perm = cmd.args.getOrNull(3) ?: return resultErr(cmd.id, usage)
+                return setPermission(cmd, pkg, permissionName(perm), action == "grant")
+            }
+            else -> return resultErr(cmd.id, usage)
+        }
+
+        val pm = context.packageManager
+        val info = pm.getPackageInfo(pkg, PackageManager.GET_PERMISSIONS)
+        val flags = info.requestedPermissionsFlags ?: IntArray(0)
+        val perms = (info.requestedPermissions ?: emptyArray()).mapIndexed { i, name ->
+            val permInfo = permissionInfo(name)
+            mapOf(
+                "permission" to name,
+                "label"      to permInfo?.loadLabel(pm)?.toString(),
+                "runtime"    to (permInfo?.protection == PermissionInfo.PROTECTION_DANGEROUS),
+                "granted"    to ((flags.getOrElse(i) { 0 } and PackageInfo.REQUESTED_PERMISSION_GRANTED) != 0)
+            )
+        }
+        return resultOk(cmd.id, mapOf(
+            "name"        to (info.applicationInfo?.let { pm.getApplicationLabel(it).toString() } ?: pkg),
+            "package"     to pkg,
+            "permissions" to perms
+        ))
+    }
+
+    private fun setPermission(cmd: CmdMsg, pkg: String, perm: String, grant: Boolean): String {
+        if (permissionInfo(perm)?.protection != PermissionInfo.PROTECTION_DANGEROUS) {
+            return resultErr(cmd.id, "$perm is not a runtime permission — it can only change by reinstalling the app")
+        }
+        val result = mapOf("package" to pkg, "permission" to perm, "granted" to grant)
+
+        Privileged.deviceOwner(context)?.let { dpm ->
+            val admin = Privileged.admin(context)
+            val state = if (grant) DevicePolicyManager.PERMISSION_GRANT_STATE_GRANTED
+                        else DevicePolicyManager.PERMISSION_GRANT_STATE_DENIED
+            if (dpm.setPermissionGrantState(admin, pkg, perm, state)) {
+                // Drop the policy again so the user can still change it in Settings
+                dpm.setPermissionGrantState(admin, pkg, perm, DevicePolicyManager.PERMISSION_GRANT_STATE_DEFAULT)
+                return resultOk(cmd.id, result + ("applied" to true))
+            }
+            // Android 12+ refuses to grant sensor permissions (camera, mic, location) this way
+        }
+
+        return try {
+            Privileged.openAppSettings(context, pkg)
+            resultOk(cmd.id, result + mapOf(
+                "applied" to false,
+                "note"    to "Opened the app's settings on the phone — change it under Permissions"
+            ))
+        } catch (e: Exception) {
+            resultErr(cmd.id, "could not open app settings: ${e.message}")
+        }
+    }
+
+    private fun whoHas(cmd: CmdMsg, perm: String): String {
+        val pm = context.packageManager
+        val includeSystem = cmd.flags.containsKey("system")
+        val apps = pm.getInstalledPackages(PackageManager.GET_PERMISSIONS)
+            .mapNotNull { info ->
+                val app = info.applicationInfo ?: return@mapNotNull null
+                val isSystem = (app.flags and ApplicationInfo.FLAG_SYSTEM) != 0
+                if (!includeSystem && isSystem) return@mapNotNull null
+                val i = info.requestedPermissions?.indexOf(perm) ?: -1
+                val granted = i >= 0 &&
+                    ((info.requestedPermissionsFlags?.getOrNull(i) ?: 0) and PackageInfo.REQUESTED_PERMISSION_GRANTED) != 0
+                if (!granted) return@mapNotNull null
+                mapOf(
+                    "name"    to pm.getApplicationLabel(app).toString(),
+                    "package" to info.packageName,
+                    "system"  to isSystem
+                )
+            }
+            .sortedBy { (it["name"] as String).lowercase() }
+        return resultOk(cmd.id, mapOf("permission" to perm, "count" to apps.size, "apps" to apps))
+    }
+
+    private fun usage(cmd: CmdMsg): String {
+        if (!AppUsage.hasAccess(context)) {
+            return resultErr(cmd.id, "Usage access not granted — grant in Settings > Apps > Special app access > Usage access > PhoneSSH")
+        }
+        val since = cmd.flags["since"]?.toLongOrNull()
+            ?: (System.currentTimeMillis() - 7 * 24 * 3600_000L)
+        val apps = AppUsage.stats(context, since)
+        return resultOk(cmd.id, mapOf("since" to since, "count" to apps.size, "apps" to apps))
+    }
+
+    private fun current(cmd: CmdMsg): String {
+        if (PshAccessibilityService.instance == null) {
+            return resultErr(cmd.id, "accessibility service not running — enable PhoneSSH in Settings > Accessibility")
+        }
+        val fg = PshAccessibilityService.currentForeground()
+            ?: return resultErr(cmd.id, "no foreground app detected yet")
+        return resultOk(cmd.id, foregroundEntry(fg))
+    }
+
+    private fun foreground(cmd: CmdMsg): String {
+        if (PshAccessibilityService.instance == null) {
+            return resultErr(cmd.id, "accessibility service not running — enable PhoneSSH in Settings > Accessibility")
+        }
+        val after = cmd.flags["after"]?.toLongOrNull() ?: Long.MAX_VALUE
+        val (seq, changes) = PshAccessibilityService.foregroundChangesAfter(after)
+        return resultOk(cmd.id, mapOf("seq" to seq, "changes" to changes.map { foregroundEntry(it) }))
+    }
+
+    private fun foregroundEntry(fg: PshAccessibilityService.ForegroundChange): Map<String, Any?> {
+        val pm = context.packageManager
+        val label = try {
+            pm.getApplicationLabel(pm.getApplicationInfo(fg.pkg, 0)).toString()
+        } catch (e: PackageManager.NameNotFoundException) {
+            null
+        }
+        return mapOf(
+            "seq"      to fg.seq,
+            "time"     to fg.time,
+            "package"  to fg.pkg,
+            "name"     to label,
+            "activity" to fg.activity
+        )
+    }
+
+    /**
+     * Privileged app controls. Clearing data and disabling (hiding) go through
+     * the device-owner API when available; anything else opens the app's
+     * settings page, where the matching button is.
+     */
+    private fun control(cmd: CmdMsg, action: String): String {
+        val query = cmd.args.getOrNull(1) ?: return resultErr(cmd.id, "usage: apps $action <name-or-package>")
+        // Disabled (hidden) apps no longer resolve by name
+        val pkg = resolvePackage(query) ?: query.takeIf { action == "enable" }
+            ?: return resultErr(cmd.id, "app not found: $query")
+        if (pkg == context.packageName) return resultErr(cmd.id, "refusing to $action PhoneSSH itself")
+
+        val dpm = Privileged.deviceOwner(context)
+        val admin = Privileged.admin(context)
+        val applied = when (action) {
+            "stop" -> {
+                // No public force-stop; this only helps for apps already in the background
+                // and may be refused; either way the settings page opens below
+                val am = context.getSystemService(Context.ACTIVITY_SERVICE) as android.app.ActivityManager
+                runCatching { am.killBackgroundProcesses(pkg) }
+                false
+            }
+            "clear-data" -> dpm != null && clearData(dpm, admin, pkg)
+            "disable"    -> dpm != null && dpm.setApplicationHidden(admin, pkg, true)
+            "enable"     -> dpm != null && (dpm.setApplicationHidden(admin, pkg, false) || !dpm.isApplicationHidden(admin, pkg))
+            else         -> false
+        }
+        if (applied) return resultOk(cmd.id, mapOf("package" to pkg, "action" to action, "applied" to true))
+
+        val button = when (action) {
+            "stop"        -> "Force stop"
+            "clear-cache" -> "Storage & cache > Clear cache"
+            "clear-data"  -> "Storage & cache > Clear storage"
+            "disable"     -> "Disable"
+            else          -> "Enable"
+        }
+        return try {
+            Privileged.openAppSettings(context, pkg)
+            resultOk(cmd.id, mapOf(
+                "package" to pkg,
+                "action"  to action,
+                "applied" to false,
+                "note"    to "Opened the app's settings on the phone — tap $button" +
+                    if (dpm == null && action in setOf("clear-data", "disable", "enable")) ". ${Privileged.SETUP_HINT}" else ""
+            ))
+        } catch (e: Exception) {
+            resultErr(cmd.id, "could not open app settings: ${e.message}")
+        }
+    }
+
+    private fun clearData(dpm: DevicePolicyManager, admin: android.content.ComponentName, pkg: String): Boolean {
+        val done = CountDownLatch(1)
+        var ok = false
+        dpm.clearApplicationUserData(admin, pkg, context.mainExecutor) { _, succeeded ->
+            ok = succeeded
+            done.countDown()
+        }
+        return done.await(15, TimeUnit.SECONDS) && ok
+    }
+
+    private fun permissionInfo(name: String): PermissionInfo? = try {
+        context.packageManager.getPermissionInfo(name, 0)
+    } catch (e: PackageManager.NameNotFoundException) {
+        null
+    }
+
+    /** CAMERA → android.permission.CAMERA; fully qualified names pass through. */
+    private fun permissionName(name: String) =
+        if (name.contains('.')) name else "android.permission.${name.uppercase()}"
+
     /** Resolve a human-readable name or partial package name to a full package name. */
     private fun resolvePackage(query: String): String? {
         val pm = context.packageManager
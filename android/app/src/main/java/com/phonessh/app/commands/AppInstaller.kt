@@ -0,0 +1,146 @@
+package com.phonessh.app.commands
+
+import android.app.PendingIntent
+import android.content.BroadcastReceiver
+import android.content.Context
+import android.content.Intent
+import android.content.IntentFilter
+import android.content.pm.PackageInstaller
+import android.content.pm.PackageManager
+import android.os.Build
+import java.io.File
+import java.util.concurrent.ConcurrentHashMap
+import java.util.concurrent.CountDownLatch
+import java.util.concurrent.TimeUnit
+
+/**
+ * Installs APKs (including split APK sets) uploaded by `psh apps install`
+ * through a PackageInstaller session, and tracks the result so the CLI can
+ * wait for it.
+ */
+object AppInstaller {
+
+    private const val ACTION_RESULT = "com.phonessh.app.INSTALL_RESULT"
+    private const val EXTRA_SESSION = "session"
+
+    private val results = ConcurrentHashMap<Int, Map<String, Any?>>()
+    private val latches = ConcurrentHashMap<Int, CountDownLatch>()
+    private val stagingDirs = ConcurrentHashMap<Int, File>()
+    @Volatile private var receiverRegistered = false
+
+    /**
+     * Creates a session from every .apk in [dir] (base.apk first) and commits
+     * it. Blocks up to [waitMs] for the outcome; returns the session id.
+     */
+    fun install(context: Context, dir: File, waitMs: Long): Int {
+        val apks = dir.listFiles { f -> f.isFile && f.name.endsWith(".apk", ignoreCase = true) }
+            ?.sortedBy { if (it.name.equals("base.apk", ignoreCase = true)) "" else it.name }
+            ?.takeIf { it.isNotEmpty() }
+            ?: throw IllegalArgumentException("no .apk files in ${dir.path}")
+
+        ensureReceiver(context.applicationContext)
+
+        val installer = context.packageManager.packageInstaller
+        val params = PackageInstaller.SessionParams(PackageInstaller.SessionParams.MODE_FULL_INSTALL)
+        if (Build.VERSION.SDK_INT >= Build.VERSION_CODES.S) {
+            // Updates of our own installs can then skip the confirmation dialog
+            params.setRequireUserAction(PackageInstaller.SessionParams.USER_ACTION_NOT_REQUIRED)
+        }
+        val sessionId = installer.createSession(params)
+        latches[sessionId] = CountDownLatch(1)
+        stagingDirs[sessionId] = dir
+
+        try {
+            installer.openSession(sessionId).use { session ->
+                for (apk in apks) {
+                    apk.inputStream().use { input ->
+                        session.openWrite(apk.name, 0, apk.length()).use { out ->
+                            input.copyTo(out)
+                            session.fsync(out)
+                        }
+                    }
+                }
+                val intent = Intent(ACTION_RESULT)
+                    .setPackage(context.packageName)
+                    .putExtra(EXTRA_SESSION, sessionId)
+                val flags = PendingIntent.FLAG_UPDATE_CURRENT or
+                    (if (Build.VERSION.SDK_INT >= Build.VERSION_CODES.S) PendingIntent.FLAG_MUTABLE else 0)
+                session.commit(PendingIntent.getBroadcast(context, sessionId, intent, flags).intentSender)
+            }
+        } catch (e: Exception) {
+            installer.abandonSession(sessionId)
+            finish(sessionId, mapOf("done" to true, "success" to false, "message" to (e.message ?: "session failed")))
+            throw e
+        }
+
+        latches[sessionId]?.await(waitMs, TimeUnit.MILLISECONDS)
+        return sessionId
+    }
+
+    /** The outcome of a session, or a pending marker while it is still running. */
+    fun status(context: Context, sessionId: Int): Map<String, Any?> {
+        val result = results[sessionId]
+            ?: return if (latches.containsKey(sessionId)) mapOf("session" to sessionId, "done" to false)
+            else mapOf("session" to sessionId, "done" to true, "success" to false, "message" to "unknown install session")
+
+        val pkg = result["package"] as String? ?: return result + ("session" to sessionId)
+        val info = try {
+            context.packageManager.getPackageInfo(pkg, 0)
+        } catch (e: PackageManager.NameNotFoundException) {
+            null
+        }
+        return result + mapOf(
+            "session"      to sessionId,
+            "version_name" to info?.versionName,
+            "version_code" to info?.longVersionCode
+        )
+    }
+
+    private fun finish(sessionId: Int, result: Map<String, Any?>) {
+        results[sessionId] = result
+        stagingDirs.remove(sessionId)?.deleteRecursively()
+        latches.remove(sessionId)?.countDown()
+    }
+
+    @Synchronized
+    private fun ensureReceiver(context: Context) {
+        if (receiverRegistered) return
+        val receiver = object : BroadcastReceiver() {
+            override fun onReceive(ctx: Context, intent: Intent) {
+                val sessionId = intent.getIntExtra(EXTRA_SESSION, -1)
+                val status = intent.getIntExtra(PackageInstaller.EXTRA_STATUS, PackageInstaller.STATUS_FAILURE)
+                if (status == PackageInstaller.STATUS_PENDING_USER_ACTION) {
+                    // Show the system confirmation; the final status arrives later
+                    @Suppress("DEPRECATION")
+                    val confirm = intent.getParcelableExtra<Intent>(Intent.EXTRA_INTENT) ?: return
+                    ctx.startActivity(confirm.addFlags(Intent.FLAG_ACTIVITY_NEW_TASK))
+                    return
+                }
+                finish(sessionId, mapOf(
+                    "done"    to true,
+                    "success" to (status == PackageInstaller.STATUS_SUCCESS),
+                    "status"  to statusName(status),
+                    "package" to intent.getStringExtra(PackageInstaller.EXTRA_PACKAGE_NAME),
+                    "message" to intent.getStringExtra(PackageInstaller.EXTRA_STATUS_MESSAGE)
+                ))
+            }
+        }
+        if (Build.VERSION.SDK_INT >= Build.VERSION_CODES.TIRAMISU) {
+            context.registerReceiver(receiver, IntentFilter(ACTION_RESULT), Context.RECEIVER_NOT_EXPORTED)
+        } else {
+            context.registerReceiver(receiver, IntentFilter(ACTION_RESULT))
+        }
+        receiverRegistered = true
+    }
+
+    private fun statusName(status: Int) = when (status) {
+        PackageInstaller.STATUS_SUCCESS              -> "success"
+        PackageInstaller.STATUS_FAILURE_ABORTED      -> "aborted"
+        PackageInstaller.STATUS_FAILURE_BLOCKED      -> "blocked"
+        PackageInstaller.STATUS_FAILURE_CONFLICT     -> "conflict"
+        PackageInstaller.STATUS_FAILURE_INCOMPATIBLE -> "incompatible"
+        PackageInstaller.STATUS_FAILURE_INVALID      -> "invalid"
+        PackageInstaller.STATUS_FAILURE_STORAGE      -> "storage"
+        else                                         -> "failure"
+    }
+}
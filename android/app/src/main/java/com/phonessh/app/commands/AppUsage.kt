@@ -0,0 +1,62 @@
+package com.phonessh.app.commands
+
+import android.app.AppOpsManager
+import android.app.usage.UsageEvents
+import android.app.usage.UsageStatsManager
+import android.content.Context
+import android.content.pm.PackageManager
+import android.os.Process
+
+/**
+ * Per-app usage from UsageStatsManager. Needs the "Usage access" special
+ * permission (Settings > Apps > Special app access > Usage access).
+ */
+object AppUsage {
+
+    fun hasAccess(context: Context): Boolean {
+        val ops = context.getSystemService(AppOpsManager::class.java)
+        return ops.unsafeCheckOpNoThrow(AppOpsManager.OPSTR_GET_USAGE_STATS,
+            Process.myUid(), context.packageName) == AppOpsManager.MODE_ALLOWED
+    }
+
+    /**
+     * Foreground time, launch count and last use for every app used since
+     * [since]. A launch is the app coming to the foreground from another app.
+     */
+    fun stats(context: Context, since: Long): List<Map<String, Any?>> {
+        val usm = context.getSystemService(UsageStatsManager::class.java)
+        val now = System.currentTimeMillis()
+
+        val launches = mutableMapOf<String, Int>()
+        val events = usm.queryEvents(since, now)
+        val event = UsageEvents.Event()
+        var foreground: String? = null
+        while (events.hasNextEvent()) {
+            events.getNextEvent(event)
+            if (event.eventType != UsageEvents.Event.ACTIVITY_RESUMED) continue
+            if (event.packageName != foreground) {
+                launches.merge(event.packageName, 1, Int::plus)
+                foreground = event.packageName
+            }
+        }
+
+        val pm = context.packageManager
+        return usm.queryAndAggregateUsageStats(since, now).values
+            .filter { it.totalTimeInForeground > 0 || launches.containsKey(it.packageName) }
+            .map { s ->
+                val label = try {
+                    pm.getApplicationLabel(pm.getApplicationInfo(s.packageName, 0)).toString()
+                } catch (e: PackageManager.NameNotFoundException) {
+                    null
+                }
+                mapOf(
+                    "package"       to s.packageName,
+                    "name"          to label,
+                    "foreground_ms" to s.totalTimeInForeground,
+                    "launches"      to (launches[s.packageName] ?: 0),
+                    "last_used"     to s.lastTimeUsed
+                )
+            }
+            .sortedByDescending { it["foreground_ms"] as Long }
+    }
+}
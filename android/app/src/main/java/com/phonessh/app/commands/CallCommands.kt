@@ -0,0 +1,155 @@
+package com.phonessh.app.commands
+
+import android.Manifest
+import android.annotation.SuppressLint
+import android.content.Context
+import android.content.Intent
+import android.content.pm.PackageManager
+import android.net.Uri
+import android.os.Build
+import android.provider.CallLog
+import android.telecom.TelecomManager
+import android.telephony.TelephonyManager
+import androidx.core.app.ActivityCompat
+import com.phonessh.app.protocol.CmdMsg
+import com.phonessh.app.protocol.resultErr
+import com.phonessh.app.protocol.resultOk
+
+class CallCommands(private val context: Context) {
+
+    /**
+     * psh calls list [--missed] [--since <epoch-ms>] [--limit <n>] [--offset <n>]
+     * psh calls dial <number>
+     * psh calls hangup
+     * psh calls state
+     */
+    fun dispatch(cmd: CmdMsg): String {
+        val subCmd = cmd.args.firstOrNull() ?: "list"
+        return when (subCmd) {
+            "list"   -> list(cmd)
+            "dial"   -> dial(cmd)
+            "hangup" -> hangup(cmd)
+            "state"  -> resultOk(cmd.id, callState(context))
+            else     -> resultErr(cmd.id, "unknown calls subcommand: $subCmd")
+        }
+    }
+
+    private fun list(cmd: CmdMsg): String {
+        if (!granted(Manifest.permission.READ_CALL_LOG)) {
+            return resultErr(cmd.id, "READ_CALL_LOG permission not granted — grant in Settings > Apps > PhoneSSH > Permissions > Call logs")
+        }
+
+        val limit = cmd.flags["limit"]?.toIntOrNull() ?: 30
+        val offset = cmd.flags["offset"]?.toIntOrNull() ?: 0
+        val clauses = mutableListOf<String>()
+        val args = mutableListOf<String>()
+        if (cmd.flags.containsKey("missed")) {
+            clauses.add("${CallLog.Calls.TYPE} IN (?, ?)")
+            args.add(CallLog.Calls.MISSED_TYPE.toString())
+            args.add(CallLog.Calls.REJECTED_TYPE.toString())
+        }
+        cmd.flags["since"]?.toLongOrNull()?.let {
+            clauses.add("${CallLog.Calls.DATE} >= ?")
+            args.add(it.toString())
+        }
+
+        val calls = mutableListOf<Map<String, Any?>>()
+        context.contentResolver.query(
+            CallLog.Calls.CONTENT_URI,
+            arrayOf(
+                CallLog.Calls._ID,
+                CallLog.Calls.NUMBER,
+                CallLog.Calls.CACHED_NAME,
+                CallLog.Calls.TYPE,
+                CallLog.Calls.DATE,
+                CallLog.Calls.DURATION,
+                CallLog.Calls.NEW
+            ),
+            clauses.joinToString(" AND ").ifEmpty { null },
+            args.toTypedArray(),
+            "${CallLog.Calls.DATE} DESC LIMIT $limit OFFSET $offset"
+        )?.use { c ->
+            while (c.moveToNext()) {
+                calls.add(mapOf(
+                    "id"       to c.getLong(0),
+                    "number"   to (c.getString(1) ?: ""),
+                    "name"     to c.getString(2),
+                    "type"     to typeName(c.getInt(3)),
+                    "time"     to c.getLong(4),
+                    "duration" to c.getLong(5),
+                    "new"      to (c.getInt(6) == 1)
+                ))
+            }
+        }
+        return resultOk(cmd.id, mapOf("count" to calls.size, "calls" to calls))
+    }
+
+    @SuppressLint("MissingPermission")
+    private fun dial(cmd: CmdMsg): String {
+        val number = cmd.args.getOrNull(1) ?: return resultErr(cmd.id, "usage: calls dial <number>")
+        val uri = Uri.fromParts("tel", number, null)
+
+        // With CALL_PHONE the call is placed directly; otherwise the dialer
+        // opens with the number filled in and the user taps call.
+        val direct = granted(Manifest.permission.CALL_PHONE)
+        return try {
+            if (direct) {
+                context.getSystemService(TelecomManager::class.java).placeCall(uri, null)
+            } else {
+                context.startActivity(Intent(Intent.ACTION_DIAL, uri).addFlags(Intent.FLAG_ACTIVITY_NEW_TASK))
+            }
+            resultOk(cmd.id, mapOf("number" to number, "placed" to direct))
+        } catch (e: Exception) {
+            resultErr(cmd.id, "dial failed: ${e.message}")
+        }
+    }
+
+    @SuppressLint("MissingPermission")
+    private fun hangup(cmd: CmdMsg): String {
+        if (Build.VERSION.SDK_INT < Build.VERSION_CODES.P) {
+            return resultErr(cmd.id, "hangup requires Android 9 or newer")
+        }
+        if (!granted(Manifest.permission.ANSWER_PHONE_CALLS)) {
+            return resultErr(cmd.id, "ANSWER_PHONE_CALLS permission not granted — grant in Settings > Apps > PhoneSSH > Permissions > Phone")
+        }
+        @Suppress("DEPRECATION")
+        val ended = context.getSystemService(TelecomManager::class.java).endCall()
+        return if (ended) resultOk(cmd.id, mapOf("ended" to true))
+        else resultErr(cmd.id, "no active call")
+    }
+
+    private fun granted(permission: String) =
+        ActivityCompat.checkSelfPermission(context, permission) == PackageManager.PERMISSION_GRANTED
+
+    companion object {
+        private fun typeName(type: Int) = when (type) {
+            CallLog.Calls.INCOMING_TYPE  -> "incoming"
+            CallLog.Calls.OUTGOING_TYPE  -> "outgoing"
+            CallLog.Calls.MISSED_TYPE    -> "missed"
+            CallLog.Calls.VOICEMAIL_TYPE -> "voicemail"
+            CallLog.Calls.REJECTED_TYPE  -> "rejected"
+            CallLog.Calls.BLOCKED_TYPE   -> "blocked"
+            else                         -> "other"
+        }
+
+        /** Current call state for `psh status`: idle, ringing or offhook. */
+        @SuppressLint("MissingPermission")
+        fun callState(context: Context): Map<String, Any?> {
+            if (ActivityCompat.checkSelfPermission(context, Manifest.permission.READ_PHONE_STATE) !=
+                PackageManager.PERMISSION_GRANTED) {
+                return mapOf("state" to "unknown", "error" to "READ_PHONE_STATE not granted")
+            }
+            return try {
+                @Suppress("DEPRECATION")
+                val state = when (context.getSystemService(TelephonyManager::class.java).callState) {
+                    TelephonyManager.CALL_STATE_RINGING -> "ringing"
+                    TelephonyManager.CALL_STATE_OFFHOOK -> "offhook"
+                    else                                -> "idle"
+                }
+                mapOf("state" to state)
+            } catch (e: Exception) {
+                mapOf("state" to "unknown", "error" to e.message)
+            }
+        }
+    }
+}
@@ -10,8 +10,11 @@ class CommandRouter(private val context: Context) {
     private val system = SystemCommands(context)
     private val notifs = NotifCommands(context)
     private val sms = SmsCommands(context)
+    private val contacts = ContactCommands(context)
+    private val calls = CallCommands(context)
     private val apps = AppCommands(context)
     private val ui = UiCommands(context)
+    private val intents = IntentCommands(context)
 
     fun dispatch(cmd: CmdMsg): String = when (cmd.cmd) {
         // ── File system ──────────────────────────────────────────────────────────
@@ -36,11 +39,17 @@ class CommandRouter(private val context: Context) {
         "lock"       -> system.lock(cmd)
 
         // ── Notifications ────────────────────────────────────────────────────────
-        "notifs"     -> notifs.list(cmd)
+        "notifs"     -> notifs.dispatch(cmd)
 
         // ── SMS ──────────────────────────────────────────────────────────────────
         "sms"        -> sms.dispatch(cmd)
 
+        // ── Contacts ─────────────────────────────────────────────────────────────
+        "contacts"   -> contacts.dispatch(cmd)
+
+        // ── Calls ────────────────────────────────────────────────────────────────
+        "calls"      -> calls.dispatch(cmd)
+
         // ── Apps ─────────────────────────────────────────────────────────────────
         "apps"       -> apps.dispatch(cmd)
 
@@ -52,6 +61,8 @@ class CommandRouter(private val context: Context) {
         "key"        -> ui.key(cmd)
         "click"      -> ui.click(cmd)
         "ui"         -> ui.ui(cmd)
+        "intent"     -> intents.dispatch(cmd)
+        "share"      -> intents.share(cmd)
 
         else         -> resultErr(cmd.id, "unknown command: ${cmd.cmd}")
     }
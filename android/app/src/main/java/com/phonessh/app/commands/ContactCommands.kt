@@ -0,0 +1,138 @@
+package com.phonessh.app.commands
+
+import android.Manifest
+import android.content.Context
+import android.content.pm.PackageManager
+import android.provider.ContactsContract
+import androidx.core.app.ActivityCompat
+import com.phonessh.app.protocol.CmdMsg
+import com.phonessh.app.protocol.resultErr
+import com.phonessh.app.protocol.resultOk
+
+class ContactCommands(private val context: Context) {
+
+    /**
+     * psh contacts list [--limit <n>] [--offset <n>]
+     * psh contacts search <query>
+     * psh contacts show <contact-id>
+     */
+    fun dispatch(cmd: CmdMsg): String {
+        if (ActivityCompat.checkSelfPermission(context, Manifest.permission.READ_CONTACTS) !=
+            PackageManager.PERMISSION_GRANTED) {
+            return resultErr(cmd.id, "READ_CONTACTS permission not granted — grant in Settings > Apps > PhoneSSH > Permissions > Contacts")
+        }
+
+        val subCmd = cmd.args.firstOrNull() ?: "list"
+        return when (subCmd) {
+            "list"   -> list(cmd)
+            "search" -> search(cmd)
+            "show"   -> show(cmd)
+            else     -> resultErr(cmd.id, "unknown contacts subcommand: $subCmd")
+        }
+    }
+
+    private fun list(cmd: CmdMsg): String {
+        val limit = cmd.flags["limit"]?.toIntOrNull()
+        val offset = cmd.flags["offset"]?.toIntOrNull() ?: 0
+        val contacts = query(null, null)
+            .drop(offset)
+            .let { if (limit != null) it.take(limit) else it }
+        return resultOk(cmd.id, mapOf("count" to contacts.size, "contacts" to contacts))
+    }
+
+    private fun search(cmd: CmdMsg): String {
+        val q = cmd.args.drop(1).joinToString(" ").trim()
+            .ifEmpty { return resultErr(cmd.id, "usage: contacts search <query>") }
+
+        // Match on name, or on the digits of a number
+        val digits = q.filter { it.isDigit() }
+        val byName = query("${ContactsContract.Contacts.DISPLAY_NAME_PRIMARY} LIKE ?", arrayOf("%$q%"))
+        val byNumber = if (digits.length >= 3) {
+            query(null, null).filter { c ->
+                @Suppress("UNCHECKED_CAST")
+                (c["numbers"] as List<Map<String, Any?>>).any {
+                    (it["number"] as String).filter { ch -> ch.isDigit() }.contains(digits)
+                }
+            }
+        } else emptyList()
+
+        val contacts = (byName + byNumber).distinctBy { it["id"] }
+        return resultOk(cmd.id, mapOf("count" to contacts.size, "contacts" to contacts))
+    }
+
+    private fun show(cmd: CmdMsg): String {
+        val id = cmd.args.getOrNull(1)?.toLongOrNull()
+            ?: return resultErr(cmd.id, "usage: contacts show <contact-id>")
+        val contact = query("${ContactsContract.Contacts._ID} = ?", arrayOf(id.toString())).firstOrNull()
+            ?: return resultErr(cmd.id, "contact not found: $id")
+        return resultOk(cmd.id, contact + mapOf("emails" to emails(id)))
+    }
+
+    /** Contacts with at least one phone number, sorted by name, each with its numbers. */
+    private fun query(selection: String?, args: Array<String>?): List<Map<String, Any?>> {
+        val numbers = phoneNumbers()
+        val out = mutableListOf<Map<String, Any?>>()
+        context.contentResolver.query(
+            ContactsContract.Contacts.CONTENT_URI,
+            arrayOf(
+                ContactsContract.Contacts._ID,
+                ContactsContract.Contacts.DISPLAY_NAME_PRIMARY,
+                ContactsContract.Contacts.STARRED
+            ),
+            selection,
+            args,
+            "${ContactsContract.Contacts.DISPLAY_NAME_PRIMARY} COLLATE NOCASE ASC"
+        )?.use { c ->
+            while (c.moveToNext()) {
+                val id = c.getLong(0)
+                val nums = numbers[id] ?: continue
+                out.add(mapOf(
+                    "id"      to id,
+                    "name"    to (c.getString(1) ?: ""),
+                    "starred" to (c.getInt(2) == 1),
+                    "numbers" to nums
+                ))
+            }
+        }
+        return out
+    }
+
+    /** contact id → list of {number, type} */
+    private fun phoneNumbers(): Map<Long, List<Map<String, Any?>>> {
+        val out = mutableMapOf<Long, MutableList<Map<String, Any?>>>()
+        context.contentResolver.query(
+            ContactsContract.CommonDataKinds.Phone.CONTENT_URI,
+            arrayOf(
+                ContactsContract.CommonDataKinds.Phone.CONTACT_ID,
+                ContactsContract.CommonDataKinds.Phone.NUMBER,
+                ContactsContract.CommonDataKinds.Phone.TYPE,
+                ContactsContract.CommonDataKinds.Phone.LABEL
+            ),
+            null, null, null
+        )?.use { c ->
+            while (c.moveToNext()) {
+                val number = c.getString(1) ?: continue
+                val type = ContactsContract.CommonDataKinds.Phone.getTypeLabel(
+                    context.resources, c.getInt(2), c.getString(3)
+                ).toString().lowercase()
+                out.getOrPut(c.getLong(0)) { mutableListOf() }
+                    .add(mapOf("number" to number, "type" to type))
+            }
+        }
+        return out
+    }
+
+    private fun emails(contactId: Long): List<String> {
+        val out = mutableListOf<String>()
+        context.contentResolver.query(
+            ContactsContract.CommonDataKinds.Email.CONTENT_URI,
+            arrayOf(ContactsContract.CommonDataKinds.Email.ADDRESS),
+            "${ContactsContract.CommonDataKinds.Email.CONTACT_ID} = ?",
+            arrayOf(contactId.toString()),
+            null
+        )?.use { c ->
+            while (c.moveToNext()) c.getString(0)?.let { out.add(it) }
+        }
+        return out
+    }
+}
@@ -6,6 +6,7 @@ import com.phonessh.app.protocol.CmdMsg
 import com.phonessh.app.protocol.resultErr
 import com.phonessh.app.protocol.resultOk
 import java.io.File
+import java.io.RandomAccessFile
 import java.util.Base64
 
 class FileCommands(private val context: Context) {
@@ -44,7 +45,11 @@ class FileCommands(private val context: Context) {
         return resultOk(cmd.id, mapOf("pattern" to pattern, "root" to root.path, "matches" to matches))
     }
 
-    /** psh pull <remote-path> — download file (base64 encoded in response) */
+    /**
+     * psh pull <remote-path> — download file (base64 encoded in response).
+     * With --length <n> only n bytes from --offset are returned, so large
+     * files can be fetched in chunks; "size" is always the full file size.
+     */
     fun pull(cmd: CmdMsg): String {
         val path = cmd.args.firstOrNull() ?: return resultErr(cmd.id, "usage: pull <path>")
         val file = File(path)
@@ -53,10 +58,22 @@ class FileCommands(private val context: Context) {
         if (!file.isFile) return resultErr(cmd.id, "not a file: $path")
         if (!file.canRead()) return resultErr(cmd.id, "permission denied: $path")
 
-        val maxSize = 50 * 1024 * 1024L // 50 MB limit
-        if (file.length() > maxSize) return resultErr(cmd.id, "file too large (>${maxSize / 1024 / 1024}MB): use chunked transfer")
+        val length = cmd.flags["length"]?.toIntOrNull()
+        val bytes = if (length != null) {
+            val offset = cmd.flags["offset"]?.toLongOrNull() ?: 0L
+            RandomAccessFile(file, "r").use { raf ->
+                raf.seek(offset)
+                val buf = ByteArray(minOf(length.toLong(), maxOf(0L, file.length() - offset)).toInt())
+                raf.readFully(buf)
+                buf
+            }
+        } else {
+            val maxSize = 50 * 1024 * 1024L // 50 MB limit
+            if (file.length() > maxSize) return resultErr(cmd.id, "file too large (>${maxSize / 1024 / 1024}MB): use chunked transfer")
+            file.readBytes()
+        }
 
-        val content = Base64.getEncoder().encodeToString(file.readBytes())
+        val content = Base64.getEncoder().encodeToString(bytes)
         return resultOk(cmd.id, mapOf(
             "filename" to file.name,
             "path" to path,
@@ -66,18 +83,30 @@ class FileCommands(private val context: Context) {
         ))
     }
 
-    /** psh push <remote-path> — upload file (base64 in payload field) */
+    /**
+     * psh push <remote-path> — upload file (base64 in payload field).
+     * Large files arrive in chunks: --offset <n> appends to a file that is
+     * already exactly n bytes long; offset 0 (the default) truncates.
+     */
     fun push(cmd: CmdMsg): String {
         val path = cmd.args.firstOrNull() ?: return resultErr(cmd.id, "usage: push <path>")
         val payload = cmd.payload ?: return resultErr(cmd.id, "no payload provided")
+        val offset = cmd.flags["offset"]?.toLongOrNull() ?: 0L
 
         val file = File(path)
         file.parentFile?.mkdirs()
 
         return try {
             val bytes = Base64.getDecoder().decode(payload)
-            file.writeBytes(bytes)
-            resultOk(cmd.id, mapOf("path" to path, "written" to bytes.size))
+            if (offset == 0L) {
+                file.writeBytes(bytes)
+            } else {
+                if (file.length() != offset) {
+                    return resultErr(cmd.id, "chunk offset $offset does not match file size ${file.length()}")
+                }
+                file.appendBytes(bytes)
+            }
+            resultOk(cmd.id, mapOf("path" to path, "written" to bytes.size, "size" to file.length()))
         } catch (e: Exception) {
             resultErr(cmd.id, "write failed: ${e.message}")
         }
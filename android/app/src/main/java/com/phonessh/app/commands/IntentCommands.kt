@@ -0,0 +1,187 @@
+package com.phonessh.app.commands
+
+import android.content.ActivityNotFoundException
+import android.content.ClipData
+import android.content.ComponentName
+import android.content.Context
+import android.content.Intent
+import android.net.Uri
+import android.webkit.MimeTypeMap
+import androidx.core.content.FileProvider
+import com.google.gson.annotations.SerializedName
+import com.phonessh.app.protocol.CmdMsg
+import com.phonessh.app.protocol.gson
+import com.phonessh.app.protocol.resultErr
+import com.phonessh.app.protocol.resultOk
+import java.io.File
+import java.util.Base64
+
+class IntentCommands(private val context: Context) {
+
+    /** Intent description sent by `psh intent`, already validated by the CLI. */
+    private data class IntentSpec(
+        val action: String? = null,
+        val data: String? = null,
+        val type: String? = null,
+        val categories: List<String>? = null,
+        val component: String? = null,
+        @SerializedName("package") val pkg: String? = null,
+        val flags: Int = 0,
+        val extras: List<Extra>? = null
+    )
+
+    private data class Extra(val key: String, val type: String, val value: String)
+
+    /**
+     * psh intent start|broadcast|service [--foreground]   (payload: base64 JSON intent spec)
+     */
+    fun dispatch(cmd: CmdMsg): String {
+        val subCmd = cmd.args.firstOrNull()
+            ?: return resultErr(cmd.id, "usage: intent [start|broadcast|service]")
+        val payload = cmd.payload ?: return resultErr(cmd.id, "no intent provided")
+        val intent = try {
+            val spec = gson.fromJson(String(Base64.getDecoder().decode(payload)), IntentSpec::class.java)
+            build(spec)
+        } catch (e: Exception) {
+            return resultErr(cmd.id, "invalid intent: ${e.message}")
+        }
+
+        return try {
+            val result = when (subCmd) {
+                "start" -> {
+                    context.startActivity(intent.addFlags(Intent.FLAG_ACTIVITY_NEW_TASK))
+                    mapOf("started" to true)
+                }
+                "broadcast" -> {
+                    context.sendBroadcast(intent)
+                    mapOf("broadcast" to true)
+                }
+                "service" -> {
+                    val name = if (cmd.flags.containsKey("foreground")) context.startForegroundService(intent)
+                               else context.startService(intent)
+                    name ?: return resultErr(cmd.id, "no service matches the intent")
+                    mapOf("service" to name.flattenToShortString())
+                }
+                else -> return resultErr(cmd.id, "unknown intent subcommand: $subCmd")
+            }
+            resultOk(cmd.id, result + ("intent" to intent.toString()))
+        } catch (e: ActivityNotFoundException) {
+            resultErr(cmd.id, "no activity handles the intent")
+        } catch (e: Exception) {
+            resultErr(cmd.id, "$subCmd failed: ${e.message}")
+        }
+    }
+
+    /**
+     * psh share <path>... [--to <package>] [--type <mime>] [--text <text>] [--subject <s>]
+     * psh share --text <text> [--to <package>]
+     *
+     * Fires ACTION_SEND (one file or text) or ACTION_SEND_MULTIPLE for files
+     * already uploaded to the phone. Without --to the share sheet is shown.
+     */
+    fun share(cmd: CmdMsg): String {
+        val files = cmd.args.map { File(it) }
+        val text = cmd.flags["text"]
+        if (files.isEmpty() && text == null) return resultErr(cmd.id, "usage: share <path>... | share --text <text>")
+        files.firstOrNull { !it.isFile }?.let { return resultErr(cmd.id, "file not found: ${it.path}") }
+
+        val uris = try {
+            files.map { FileProvider.getUriForFile(context, "${context.packageName}.files", it) }
+        } catch (e: IllegalArgumentException) {
+            return resultErr(cmd.id, "can only share files under $STAGING_DIR: ${e.message}")
+        }
+        if (files.isNotEmpty()) cleanupShares()
+        val type = cmd.flags["type"] ?: when {
+            files.isEmpty() -> "text/plain"
+            else -> files.map { mimeOf(it) }.distinct().singleOrNull()
+                ?: files.map { mimeOf(it).substringBefore('/') }.distinct().singleOrNull()?.let { "$it/*" }
+                ?: "*/*"
+        }
+
+        val intent = Intent(if (uris.size > 1) Intent.ACTION_SEND_MULTIPLE else Intent.ACTION_SEND).apply {
+            this.type = type
+            text?.let { putExtra(Intent.EXTRA_TEXT, it) }
+            cmd.flags["subject"]?.let { putExtra(Intent.EXTRA_SUBJECT, it) }
+            when (uris.size) {
+                0 -> {}
+                1 -> putExtra(Intent.EXTRA_STREAM, uris[0])
+                else -> putParcelableArrayListExtra(Intent.EXTRA_STREAM, ArrayList(uris))
+            }
+            if (uris.isNotEmpty()) {
+                // ClipData carries the read grant to the receiver for every URI
+                clipData = ClipData.newRawUri(null, uris[0]).also { clip ->
+                    uris.drop(1).forEach { clip.addItem(ClipData.Item(it)) }
+                }
+                addFlags(Intent.FLAG_GRANT_READ_URI_PERMISSION)
+            }
+        }
+
+        val target = cmd.flags["to"]
+        return try {
+            if (target != null) {
+                intent.setPackage(target)
+                context.startActivity(intent.addFlags(Intent.FLAG_ACTIVITY_NEW_TASK))
+            } else {
+                context.startActivity(Intent.createChooser(intent, null).addFlags(Intent.FLAG_ACTIVITY_NEW_TASK))
+            }
+            resultOk(cmd.id, mapOf("shared" to files.size, "type" to type, "to" to target))
+        } catch (e: ActivityNotFoundException) {
+            resultErr(cmd.id, if (target != null) "$target cannot receive $type shares" else "no app can receive $type shares")
+        } catch (e: Exception) {
+            resultErr(cmd.id, "share failed: ${e.message}")
+        }
+    }
+
+    private fun mimeOf(file: File) =
+        MimeTypeMap.getSingleton().getMimeTypeFromExtension(file.extension.lowercase()) ?: "application/octet-stream"
+
+    /** Receivers read shared files lazily, so uploads are kept for a day. */
+    private fun cleanupShares() {
+        val cutoff = System.currentTimeMillis() - 24 * 3600_000L
+        File(STAGING_DIR).listFiles { f -> f.isDirectory && f.name.startsWith("share-") && f.lastModified() < cutoff }
+            ?.forEach { it.deleteRecursively() }
+    }
+
+    private fun build(spec: IntentSpec): Intent {
+        val intent = Intent()
+        spec.action?.let { intent.action = it }
+        val uri = spec.data?.let { Uri.parse(it) }
+        when {
+            uri != null && spec.type != null -> intent.setDataAndType(uri, spec.type)
+            uri != null                      -> intent.data = uri
+            spec.type != null                -> intent.type = spec.type
+        }
+        spec.categories?.forEach { intent.addCategory(it) }
+        spec.component?.let {
+            intent.component = ComponentName.unflattenFromString(it)
+                ?: throw IllegalArgumentException("bad component: $it")
+        }
+        spec.pkg?.let { intent.setPackage(it) }
+        intent.addFlags(spec.flags)
+
+        for (e in spec.extras ?: emptyList()) {
+            when (e.type) {
+                "string"   -> intent.putExtra(e.key, e.value)
+                "int"      -> intent.putExtra(e.key, e.value.toInt())
+                "long"     -> intent.putExtra(e.key, e.value.toLong())
+                "float"    -> intent.putExtra(e.key, e.value.toFloat())
+                "double"   -> intent.putExtra(e.key, e.value.toDouble())
+                "bool"     -> intent.putExtra(e.key, e.value.toBooleanStrict())
+                "uri"      -> intent.putExtra(e.key, Uri.parse(e.value))
+                "string[]" -> intent.putExtra(e.key, splitList(e.value).toTypedArray())
+                "int[]"    -> intent.putExtra(e.key, splitList(e.value).map { it.toInt() }.toIntArray())
+                "long[]"   -> intent.putExtra(e.key, splitList(e.value).map { it.toLong() }.toLongArray())
+                else       -> throw IllegalArgumentException("unknown extra type ${e.type} for ${e.key}")
+            }
+        }
+        return intent
+    }
+
+    private fun splitList(value: String) =
+        if (value.isEmpty()) emptyList() else value.split(",")
+
+    companion object {
+        /** Where the CLI uploads files to share; the FileProvider only serves this dir. */
+        private const val STAGING_DIR = "/sdcard/Download/.psh"
+    }
+}
@@ -0,0 +1,324 @@
+package com.phonessh.app.commands
+
+import android.app.Activity
+import android.app.PendingIntent
+import android.content.BroadcastReceiver
+import android.content.Context
+import android.content.Intent
+import android.net.Uri
+import android.telephony.SmsManager
+import androidx.core.content.FileProvider
+import java.io.ByteArrayOutputStream
+import java.io.File
+import java.util.concurrent.ConcurrentHashMap
+import java.util.concurrent.CountDownLatch
+import java.util.concurrent.TimeUnit
+import java.util.concurrent.atomic.AtomicInteger
+
+/**
+ * MMS support for the sms commands: reading MMS rows (with attachment
+ * metadata) in the same shape as SMS rows, fetching attachment bytes, and
+ * sending group / attachment messages.
+ */
+object Mms {
+
+    /** Text parts are folded into the body; everything else is an attachment. */
+    private val TEXT_TYPES = setOf("text/plain", "application/smil")
+
+    // PDU address types in content://mms/<id>/addr
+    private const val ADDR_FROM = 137
+    private const val ADDR_TO = 151
+    private const val ADDR_CC = 130
+
+    /** Packages that may host the system MMS service and need to read our PDU. */
+    private val MMS_SERVICE_PACKAGES = listOf("com.android.phone", "com.android.mms.service")
+
+    /**
+     * MMS rows, newest first. [since] and [before] are epoch millis; MMS dates
+     * are stored in seconds.
+     */
+    fun list(
+        context: Context,
+        unreadOnly: Boolean = false,
+        fromFilter: String? = null,
+        since: Long? = null,
+        before: Long? = null,
+        threadId: Long? = null,
+        limit: Int,
+        beforeKind: String? = null,
+        beforeId: Long? = null
+    ): List<Map<String, Any?>> {
+        val clauses = mutableListOf<String>()
+        val args = mutableListOf<String>()
+        if (unreadOnly) clauses.add("read = 0")
+        since?.let { clauses.add("date >= ?"); args.add((it / 1000).toString()) }
+        // With a (time, kind, id) cursor: MMS dates are in seconds, and within
+        // the same time SMS sort before MMS (see SmsCommands.pageOrder)
+        before?.let { t ->
+            val secs = (t / 1000).toString()
+            when {
+                beforeId == null -> { clauses.add("date < ?"); args.add(secs) }
+                beforeKind == "mms" -> { clauses.add("(date < ? OR (date = ? AND _id < ?))"); args.addAll(listOf(secs, secs, beforeId.toString())) }
+                else -> { clauses.add("date <= ?"); args.add(secs) }
+            }
+        }
+        threadId?.let { clauses.add("thread_id = ?"); args.add(it.toString()) }
+
+        val out = mutableListOf<Map<String, Any?>>()
+        context.contentResolver.query(
+            Uri.parse("content://mms"),
+            arrayOf("_id", "thread_id", "date", "read", "msg_box", "sub"),
+            clauses.joinToString(" AND ").ifEmpty { null },
+            args.toTypedArray(),
+            // The from filter needs the addr table, so it can't be limited in SQL
+            if (fromFilter == null) "date DESC, _id DESC LIMIT $limit" else "date DESC, _id DESC"
+        )?.use { c ->
+            while (out.size < limit && c.moveToNext()) {
+                val id = c.getLong(0)
+                val addrs = addresses(context, id)
+                val from = addrs[ADDR_FROM]?.firstOrNull() ?: ""
+                val to = (addrs[ADDR_TO] ?: emptyList()) + (addrs[ADDR_CC] ?: emptyList())
+                if (fromFilter != null && (listOf(from) + to).none { it.contains(fromFilter) }) continue
+
+                val (text, attachments) = parts(context, id)
+                val box = c.getInt(4)
+                out.add(mapOf(
+                    "id"          to id,
+                    "kind"        to "mms",
+                    "thread_id"   to c.getLong(1),
+                    "from"        to from,
+                    "to"          to to,
+                    "body"        to listOfNotNull(c.getString(5), text).filter { it.isNotBlank() }.joinToString("\n"),
+                    "time"        to c.getLong(2) * 1000,
+                    "read"        to (c.getInt(3) == 1),
+                    "type"        to when (box) {
+                        1 -> "inbox"
+                        2 -> "sent"
+                        3 -> "draft"
+                        4 -> "outbox"
+                        else -> "other"
+                    },
+                    "attachments" to attachments
+                ))
+            }
+        }
+        return out
+    }
+
+    /** Attachment bytes and metadata for an MMS part id. */
+    fun attachment(context: Context, partId: Long): Pair<Map<String, Any?>, ByteArray>? {
+        var meta: Map<String, Any?>? = null
+        context.contentResolver.query(
+            Uri.parse("content://mms/part"),
+            arrayOf("_id", "mid", "ct", "name", "cl"),
+            "_id = ?",
+            arrayOf(partId.toString()),
+            null
+        )?.use { c ->
+            if (c.moveToFirst()) {
+                meta = mapOf(
+                    "part_id"      to c.getLong(0),
+                    "message_id"   to c.getLong(1),
+                    "content_type" to (c.getString(2) ?: "application/octet-stream"),
+                    "filename"     to (c.getString(3) ?: c.getString(4) ?: "part-$partId")
+                )
+            }
+        }
+        val m = meta ?: return null
+        val bytes = context.contentResolver.openInputStream(Uri.parse("content://mms/part/$partId"))
+            ?.use { it.readBytes() } ?: return null
+        return m to bytes
+    }
+
+    class Attachment(val name: String, val mime: String, val data: ByteArray)
+
+    /** [confirmed] is false when the MMS service had not reported back in time. */
+    class SendResult(val bytes: Int, val confirmed: Boolean)
+
+    /** How long send waits for the MMS service, within the CLI's command timeout. */
+    private const val SEND_WAIT_SECONDS = 45L
+
+    private const val EXTRA_REQUEST = "request"
+    private const val EXTRA_PDU = "pdu"
+
+    /** Callbacks of sends still waiting for [MmsSentReceiver], by request code. */
+    private val pendingSends = ConcurrentHashMap<Int, (Int) -> Unit>()
+    private val nextRequest = AtomicInteger()
+
+    /**
+     * Sends an MMS to [recipients] (a group message when there are several).
+     * The PDU is written to the cache dir and handed to the system MMS service
+     * through our FileProvider. Waits for the service's result and throws if
+     * it reports a failure.
+     */
+    fun send(context: Context, recipients: List<String>, text: String, attachments: List<Attachment>): SendResult {
+        val pdu = encodeSendReq(recipients, text, attachments)
+
+        val maxSize = runCatching {
+            context.getSystemService(SmsManager::class.java)
+                .carrierConfigValues.getInt(SmsManager.MMS_CONFIG_MAX_MESSAGE_SIZE)
+        }.getOrDefault(0)
+        if (maxSize > 0 && pdu.size > maxSize) {
+            throw IllegalArgumentException("message is ${pdu.size / 1024} KB; carrier limit is ${maxSize / 1024} KB")
+        }
+
+        val dir = File(context.cacheDir, "mms").apply { mkdirs() }
+        // MmsSentReceiver deletes each PDU; this catches any it never heard about
+        dir.listFiles()?.filter { System.currentTimeMillis() - it.lastModified() > 60 * 60 * 1000 }
+            ?.forEach { it.delete() }
+        val file = File(dir, "send-${System.currentTimeMillis()}.pdu")
+        file.writeBytes(pdu)
+        val uri = FileProvider.getUriForFile(context, "${context.packageName}.files", file)
+        for (pkg in MMS_SERVICE_PACKAGES) {
+            context.grantUriPermission(pkg, uri, Intent.FLAG_GRANT_READ_URI_PERMISSION)
+        }
+
+        val request = nextRequest.incrementAndGet()
+        val done = CountDownLatch(1)
+        var resultCode = Activity.RESULT_OK
+        pendingSends[request] = { code -> resultCode = code; done.countDown() }
+        val sent = PendingIntent.getBroadcast(
+            context, request,
+            Intent(context, MmsSentReceiver::class.java)
+                .putExtra(EXTRA_REQUEST, request)
+                .putExtra(EXTRA_PDU, file.path),
+            PendingIntent.FLAG_ONE_SHOT or PendingIntent.FLAG_IMMUTABLE
+        )
+        try {
+            context.getSystemService(SmsManager::class.java)
+                .sendMultimediaMessage(context, uri, null, null, sent)
+        } catch (e: Exception) {
+            pendingSends.remove(request)
+            file.delete()
+            throw e
+        }
+
+        if (!done.await(SEND_WAIT_SECONDS, TimeUnit.SECONDS)) {
+            pendingSends.remove(request)
+            return SendResult(pdu.size, confirmed = false)
+        }
+        if (resultCode != Activity.RESULT_OK) {
+            throw IllegalStateException(sendError(resultCode))
+        }
+        return SendResult(pdu.size, confirmed = true)
+    }
+
+    private fun sendError(code: Int) = when (code) {
+        SmsManager.MMS_ERROR_INVALID_APN -> "invalid APN settings"
+        SmsManager.MMS_ERROR_UNABLE_CONNECT_MMS -> "could not connect to the carrier's MMS server"
+        SmsManager.MMS_ERROR_HTTP_FAILURE -> "the carrier's MMS server returned an HTTP error"
+        SmsManager.MMS_ERROR_IO_ERROR -> "I/O error while sending"
+        SmsManager.MMS_ERROR_CONFIGURATION_ERROR -> "carrier MMS configuration error"
+        SmsManager.MMS_ERROR_NO_DATA_NETWORK -> "no mobile data connection"
+        else -> "the MMS service reported error $code"
+    }
+
+    /** Result of sendMultimediaMessage: wakes the waiting send and deletes its PDU. */
+    class MmsSentReceiver : BroadcastReceiver() {
+        override fun onReceive(context: Context, intent: Intent) {
+            intent.getStringExtra(EXTRA_PDU)?.let { File(it).delete() }
+            pendingSends.remove(intent.getIntExtra(EXTRA_REQUEST, -1))?.invoke(resultCode)
+        }
+    }
+
+    private fun addresses(context: Context, mmsId: Long): Map<Int, List<String>> {
+        val out = mutableMapOf<Int, MutableList<String>>()
+        context.contentResolver.query(
+            Uri.parse("content://mms/$mmsId/addr"),
+            arrayOf("address", "type"),
+            null, null, null
+        )?.use { c ->
+            while (c.moveToNext()) {
+                val addr = c.getString(0) ?: continue
+                if (addr == "insert-address-token") continue
+                out.getOrPut(c.getInt(1)) { mutableListOf() }.add(addr)
+            }
+        }
+        return out
+    }
+
+    /** Joined text of the text parts, and metadata for the others. */
+    private fun parts(context: Context, mmsId: Long): Pair<String?, List<Map<String, Any?>>> {
+        val text = mutableListOf<String>()
+        val attachments = mutableListOf<Map<String, Any?>>()
+        context.contentResolver.query(
+            Uri.parse("content://mms/part"),
+            arrayOf("_id", "ct", "text", "name", "cl"),
+            "mid = ?",
+            arrayOf(mmsId.toString()),
+            null
+        )?.use { c ->
+            while (c.moveToNext()) {
+                val ct = c.getString(1) ?: continue
+                when {
+                    ct == "text/plain" -> c.getString(2)?.let { text.add(it) }
+                    ct in TEXT_TYPES -> {}
+                    else -> attachments.add(mapOf(
+                        "part_id"      to c.getLong(0),
+                        "content_type" to ct,
+                        "filename"     to (c.getString(3) ?: c.getString(4) ?: "part-${c.getLong(0)}")
+                    ))
+                }
+            }
+        }
+        return text.joinToString("\n").ifEmpty { null } to attachments
+    }
+
+    // ── M-Send.req PDU encoding (OMA MMS encapsulation 1.2) ──────────────────
+
+    private fun encodeSendReq(recipients: List<String>, text: String, attachments: List<Attachment>): ByteArray {
+        val out = ByteArrayOutputStream()
+        out.write(0x8C); out.write(0x80)                       // X-Mms-Message-Type: m-send-req
+        out.write(0x98); writeText(out, "T${System.currentTimeMillis()}") // X-Mms-Transaction-ID
+        out.write(0x8D); out.write(0x92)                       // X-Mms-MMS-Version: 1.2
+        out.write(0x89); out.write(0x01); out.write(0x81)      // From: insert-address-token
+        for (r in recipients) {
+            out.write(0x97); writeText(out, "$r/TYPE=PLMN")    // To
+        }
+        out.write(0x84); out.write(0xA3)                       // Content-Type: application/vnd.wap.multipart.mixed
+
+        val parts = mutableListOf<Triple<String, String, ByteArray>>()
+        if (text.isNotEmpty()) parts.add(Triple("text/plain", "text.txt", text.toByteArray(Charsets.UTF_8)))
+        attachments.forEach { parts.add(Triple(it.mime, it.name, it.data)) }
+
+        writeUintVar(out, parts.size.toLong())
+        for ((mime, name, data) in parts) {
+            val headers = ByteArrayOutputStream()
+            if (mime == "text/plain") {
+                // Content-type with charset=utf-8 (0x81 = charset, 0xEA = utf-8)
+                val ct = ByteArrayOutputStream()
+                writeText(ct, mime); ct.write(0x81); ct.write(0xEA)
+                writeValueLength(headers, ct.size()); headers.write(ct.toByteArray())
+            } else {
+                writeText(headers, mime)
+            }
+            headers.write(0x8E); writeText(headers, name)      // Content-Location
+            writeUintVar(out, headers.size().toLong())
+            writeUintVar(out, data.size.toLong())
+            out.write(headers.toByteArray())
+            out.write(data)
+        }
+        return out.toByteArray()
+    }
+
+    private fun writeText(out: ByteArrayOutputStream, s: String) {
+        val bytes = s.toByteArray(Charsets.UTF_8)
+        if (bytes.isNotEmpty() && (bytes[0].toInt() and 0xFF) >= 0x80) out.write(0x7F) // quote
+        out.write(bytes)
+        out.write(0)
+    }
+
+    private fun writeValueLength(out: ByteArrayOutputStream, len: Int) {
+        if (len < 31) out.write(len) else { out.write(31); writeUintVar(out, len.toLong()) }
+    }
+
+    private fun writeUintVar(out: ByteArrayOutputStream, value: Long) {
+        val bytes = mutableListOf((value and 0x7F).toInt())
+        var v = value shr 7
+        while (v > 0) {
+            bytes.add(0, ((v and 0x7F) or 0x80).toInt())
+            v = v shr 7
+        }
+        bytes.forEach { out.write(it) }
+    }
+}
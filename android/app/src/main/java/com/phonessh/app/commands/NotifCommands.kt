@@ -9,14 +9,28 @@ import com.phonessh.app.protocol.resultOk
 class NotifCommands(private val context: Context) {
 
     /**
-     * psh notifs                     — list recent notifications
-     * psh notifs --clear <app>       — cancel notifications from an app
-     * psh notifs --clear-all         — cancel all notifications
+     * psh notifs [--app <name>] [--clear <app>] [--clear-all]
+     * psh notifs snooze <key> --duration <ms>
+     * psh notifs snooze --app <name> --duration <ms>
+     * psh notifs mute <app> [--for <ms>]
+     * psh notifs unmute <app>
+     * psh notifs muted
      */
-    fun list(cmd: CmdMsg): String {
+    fun dispatch(cmd: CmdMsg): String {
         val listener = PshNotificationListenerService.instance
             ?: return resultErr(cmd.id, "Notification Listener not enabled — grant in Settings > Apps > Special app access > Notification access > PhoneSSH")
 
+        return when (val subCmd = cmd.args.firstOrNull()) {
+            null, "list" -> list(cmd, listener)
+            "snooze"     -> snooze(cmd, listener)
+            "mute"       -> mute(cmd, listener)
+            "unmute"     -> unmute(cmd, listener)
+            "muted"      -> muted(cmd, listener)
+            else         -> resultErr(cmd.id, "unknown notifs subcommand: $subCmd")
+        }
+    }
+
+    private fun list(cmd: CmdMsg, listener: PshNotificationListenerService): String {
         // Handle --clear
         if (cmd.flags.containsKey("clear")) {
             val pkg = cmd.flags["clear"]
@@ -52,4 +66,46 @@ class NotifCommands(private val context: Context) {
             "notifications" to notifs
         ))
     }
+
+    private fun snooze(cmd: CmdMsg, listener: PshNotificationListenerService): String {
+        val durationMs = cmd.flags["duration"]?.toLongOrNull()
+            ?: return resultErr(cmd.id, "usage: notifs snooze <key|--app name> --duration <ms>")
+        if (durationMs <= 0) return resultErr(cmd.id, "duration must be positive")
+
+        val app = cmd.flags["app"]
+        if (app != null) {
+            val snoozed = listener.snoozeApp(app, durationMs)
+            return resultOk(cmd.id, mapOf("snoozed" to snoozed, "app" to app, "duration_ms" to durationMs))
+        }
+
+        val key = cmd.args.getOrNull(1)
+            ?: return resultErr(cmd.id, "usage: notifs snooze <key|--app name> --duration <ms>")
+        if (!listener.snooze(key, durationMs)) return resultErr(cmd.id, "no notification with key: $key")
+        return resultOk(cmd.id, mapOf("snoozed" to 1, "key" to key, "duration_ms" to durationMs))
+    }
+
+    private fun mute(cmd: CmdMsg, listener: PshNotificationListenerService): String {
+        val app = cmd.args.getOrNull(1) ?: return resultErr(cmd.id, "usage: notifs mute <app> [--for <ms>]")
+        val forMs = cmd.flags["for"]?.let { value ->
+            value.toLongOrNull()?.takeIf { it > 0 }
+                ?: return resultErr(cmd.id, "--for must be a positive number of ms, got: $value")
+        }
+        val until = if (forMs != null) System.currentTimeMillis() + forMs else 0L
+
+        listener.mute(app, until)
+        // Also clear what the app has already posted
+        val cleared = listener.clearNotifications(app)
+        return resultOk(cmd.id, mapOf("muted" to app, "until" to until, "cleared" to cleared))
+    }
+
+    private fun unmute(cmd: CmdMsg, listener: PshNotificationListenerService): String {
+        val app = cmd.args.getOrNull(1) ?: return resultErr(cmd.id, "usage: notifs unmute <app>")
+        if (!listener.unmute(app)) return resultErr(cmd.id, "not muted: $app")
+        return resultOk(cmd.id, mapOf("unmuted" to app))
+    }
+
+    private fun muted(cmd: CmdMsg, listener: PshNotificationListenerService): String {
+        val muted = listener.mutedApps().map { (app, until) -> mapOf("app" to app, "until" to until) }
+        return resultOk(cmd.id, mapOf("count" to muted.size, "muted" to muted))
+    }
 }
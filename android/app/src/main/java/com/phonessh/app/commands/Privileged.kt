@@ -0,0 +1,35 @@
+package com.phonessh.app.commands
+
+import android.app.admin.DevicePolicyManager
+import android.content.ComponentName
+import android.content.Context
+import android.content.Intent
+import android.net.Uri
+import android.provider.Settings
+import com.phonessh.app.PshDeviceAdminReceiver
+
+/**
+ * Privileged operations on other apps. They work when PhoneSSH is device
+ * owner; otherwise callers fall back to opening the relevant settings screen.
+ */
+object Privileged {
+
+    const val SETUP_HINT = "make PhoneSSH device owner for direct control: " +
+        "adb shell dpm set-device-owner com.phonessh.app/.PshDeviceAdminReceiver"
+
+    fun admin(context: Context) = ComponentName(context, PshDeviceAdminReceiver::class.java)
+
+    /** The policy manager when PhoneSSH is device owner, otherwise null. */
+    fun deviceOwner(context: Context): DevicePolicyManager? {
+        val dpm = context.getSystemService(DevicePolicyManager::class.java) ?: return null
+        return dpm.takeIf { it.isDeviceOwnerApp(context.packageName) }
+    }
+
+    /** Opens Settings > Apps > <pkg>, where the user can finish the job. */
+    fun openAppSettings(context: Context, pkg: String) {
+        context.startActivity(
+            Intent(Settings.ACTION_APPLICATION_DETAILS_SETTINGS, Uri.fromParts("package", pkg, null))
+                .addFlags(Intent.FLAG_ACTIVITY_NEW_TASK)
+        )
+    }
+}
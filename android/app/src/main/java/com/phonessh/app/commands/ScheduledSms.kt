@@ -0,0 +1,127 @@
+package com.phonessh.app.commands
+
+import android.app.AlarmManager
+import android.app.PendingIntent
+import android.content.BroadcastReceiver
+import android.content.Context
+import android.content.Intent
+import android.os.Build
+import android.util.Log
+import com.google.gson.reflect.TypeToken
+import com.phonessh.app.protocol.gson
+
+/**
+ * SMS queued on the phone by `psh sms send --at ... --on-phone`, so they go
+ * out even when the laptop is asleep. Entries live in SharedPreferences and
+ * are armed with AlarmManager; [rearm] restores the alarms after a reboot.
+ */
+object ScheduledSms {
+
+    private const val TAG = "ScheduledSms"
+    private const val PREFS = "psh_scheduled_sms"
+    private const val KEY = "entries"
+    private const val EXTRA_ID = "id"
+
+    /** Sent and failed entries are kept this long so `scheduled list` can show them. */
+    private const val KEEP_DONE_MS = 7L * 24 * 60 * 60 * 1000
+
+    @Synchronized
+    fun add(context: Context, to: String, body: String, at: Long): Map<String, Any?> {
+        val entries = load(context)
+        val id = (entries.maxOfOrNull { (it["id"] as Number).toLong() } ?: 0L) + 1
+        val entry = mutableMapOf<String, Any?>(
+            "id" to id,
+            "to" to to,
+            "body" to body,
+            "at" to at,
+            "created" to System.currentTimeMillis(),
+            "status" to "pending"
+        )
+        entries.add(entry)
+        save(context, entries)
+        arm(context, id, at)
+        return entry
+    }
+
+    @Synchronized
+    fun list(context: Context): List<Map<String, Any?>> = load(context)
+
+    @Synchronized
+    fun cancel(context: Context, id: Long): Boolean {
+        val entries = load(context)
+        val entry = entries.find { (it["id"] as Number).toLong() == id && it["status"] == "pending" }
+            ?: return false
+        entry["status"] = "cancelled"
+        save(context, entries)
+        alarmManager(context).cancel(pendingIntent(context, id))
+        return true
+    }
+
+    /** Re-arms every pending entry; overdue ones fire immediately. */
+    @Synchronized
+    fun rearm(context: Context) {
+        for (e in load(context)) {
+            if (e["status"] == "pending") arm(context, (e["id"] as Number).toLong(), (e["at"] as Number).toLong())
+        }
+    }
+
+    @Synchronized
+    internal fun fire(context: Context, id: Long) {
+        val entries = load(context)
+        val entry = entries.find { (it["id"] as Number).toLong() == id && it["status"] == "pending" } ?: return
+        try {
+            SmsCommands.sendText(context, entry["to"] as String, entry["body"] as String)
+            entry["status"] = "sent"
+        } catch (e: Exception) {
+            Log.w(TAG, "scheduled SMS $id failed", e)
+            entry["status"] = "failed"
+            entry["error"] = e.message
+        }
+        entry["sent_at"] = System.currentTimeMillis()
+        save(context, entries)
+    }
+
+    private fun arm(context: Context, id: Long, at: Long) {
+        val am = alarmManager(context)
+        val pi = pendingIntent(context, id)
+        if (Build.VERSION.SDK_INT < Build.VERSION_CODES.S || am.canScheduleExactAlarms()) {
+            am.setExactAndAllowWhileIdle(AlarmManager.RTC_WAKEUP, at, pi)
+        } else {
+            // Without the exact-alarm permission the system may delay delivery a little
+            am.setAndAllowWhileIdle(AlarmManager.RTC_WAKEUP, at, pi)
+        }
+    }
+
+    private fun alarmManager(context: Context) =
+        context.getSystemService(Context.ALARM_SERVICE) as AlarmManager
+
+    private fun pendingIntent(context: Context, id: Long): PendingIntent =
+        PendingIntent.getBroadcast(
+            context,
+            id.toInt(),
+            Intent(context, ScheduledSmsReceiver::class.java).putExtra(EXTRA_ID, id),
+            PendingIntent.FLAG_UPDATE_CURRENT or PendingIntent.FLAG_IMMUTABLE
+        )
+
+    private fun load(context: Context): MutableList<MutableMap<String, Any?>> {
+        val json = context.getSharedPreferences(PREFS, Context.MODE_PRIVATE).getString(KEY, null)
+            ?: return mutableListOf()
+        val type = object : TypeToken<MutableList<MutableMap<String, Any?>>>() {}.type
+        val entries: MutableList<MutableMap<String, Any?>> = gson.fromJson(json, type)
+        val cutoff = System.currentTimeMillis() - KEEP_DONE_MS
+        entries.removeAll { it["status"] != "pending" && (it["at"] as Number).toLong() < cutoff }
+        return entries
+    }
+
+    private fun save(context: Context, entries: List<Map<String, Any?>>) {
+        context.getSharedPreferences(PREFS, Context.MODE_PRIVATE)
+            .edit().putString(KEY, gson.toJson(entries)).apply()
+    }
+
+    class ScheduledSmsReceiver : BroadcastReceiver() {
+        override fun onReceive(context: Context, intent: Intent) {
+            val id = intent.getLongExtra(EXTRA_ID, -1)
+            if (id >= 0) fire(context, id)
+        }
+    }
+}
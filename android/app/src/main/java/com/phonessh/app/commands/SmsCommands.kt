@@ -4,26 +4,48 @@ import android.Manifest
 import android.content.ContentValues
 import android.content.Context
 import android.content.pm.PackageManager
+import android.database.Cursor
 import android.net.Uri
+import android.provider.ContactsContract
+import android.provider.Telephony
 import android.telephony.SmsManager
 import androidx.core.app.ActivityCompat
+import com.google.gson.reflect.TypeToken
 import com.phonessh.app.protocol.CmdMsg
+import com.phonessh.app.protocol.gson
 import com.phonessh.app.protocol.resultErr
 import com.phonessh.app.protocol.resultOk
+import java.util.Base64
 
 class SmsCommands(private val context: Context) {
 
     /**
-     * psh sms list [--unread] [--from <number>] [--limit <n>]
+     * psh sms list [--unread] [--from <number>] [--limit <n>] [--offset <n>] [--since <epoch-ms>] [--thread <id>] [--mms]
      * psh sms send <number> <message>
+     * psh sms send-mms <message...> --to <n1,n2,...>   (payload: base64 JSON array of {name, mime, data})
+     * psh sms attachment <part-id>
+     * psh sms search <query> [--regex] [--since <epoch-ms>] [--from <number>] [--limit <n>] [--context <n>]
      * psh sms conversations
+     * psh sms thread <thread:id|number|contact-name> [--before <epoch-ms> [--before-kind sms|mms --before-id <id>]] [--limit <n>] [--mark-read]
+     * psh sms import   (payload: base64 JSON array of {address, body, date, type, read})
+     * psh sms schedule <number> <message> --at <epoch-ms>
+     * psh sms scheduled
+     * psh sms unschedule <id>
      */
     fun dispatch(cmd: CmdMsg): String {
-        val subCmd = cmd.args.firstOrNull() ?: return resultErr(cmd.id, "usage: sms [list|send|conversations]")
+        val subCmd = cmd.args.firstOrNull() ?: return resultErr(cmd.id, "usage: sms [list|send|conversations|thread]")
         return when (subCmd) {
             "list"          -> list(cmd)
             "send"          -> send(cmd)
+            "send-mms"      -> sendMms(cmd)
+            "attachment"    -> attachment(cmd)
+            "search"        -> search(cmd)
             "conversations" -> conversations(cmd)
+            "thread"        -> thread(cmd)
+            "import"        -> importMessages(cmd)
+            "schedule"      -> schedule(cmd)
+            "scheduled"     -> resultOk(cmd.id, mapOf("scheduled" to ScheduledSms.list(context)))
+            "unschedule"    -> unschedule(cmd)
             else            -> resultErr(cmd.id, "unknown sms subcommand: $subCmd")
         }
     }
@@ -34,6 +56,9 @@ class SmsCommands(private val context: Context) {
         val unreadOnly = cmd.flags.containsKey("unread")
         val fromFilter = cmd.flags["from"]
         val limit = cmd.flags["limit"]?.toIntOrNull() ?: 30
+        val offset = cmd.flags["offset"]?.toIntOrNull() ?: 0
+        val since = cmd.flags["since"]?.toLongOrNull()
+        val threadId = cmd.flags["thread"]?.toLongOrNull()
 
         val selection = buildString {
             if (unreadOnly) append("read = 0")
@@ -41,38 +66,162 @@ class SmsCommands(private val context: Context) {
                 if (isNotEmpty()) append(" AND ")
                 append("address LIKE '%${fromFilter.replace("'", "")}%'")
             }
+            if (since != null) {
+                if (isNotEmpty()) append(" AND ")
+                append("date >= $since")
+            }
+            if (threadId != null) {
+                if (isNotEmpty()) append(" AND ")
+                append("thread_id = $threadId")
+            }
         }.takeIf { it.isNotEmpty() }
 
-        val messages = mutableListOf<Map<String, Any?>>()
+        // With MMS, page over the merge of both tables: fetch the first
+        // limit+offset of each, then cut the page out of the combined list.
+        val includeMms = cmd.flags.containsKey("mms")
+        val fetch = if (includeMms) limit + offset else limit
+        val skip = if (includeMms) 0 else offset
+
+        var messages = mutableListOf<Map<String, Any?>>()
         val cursor = context.contentResolver.query(
             Uri.parse("content://sms"),
-            arrayOf("_id", "address", "body", "date", "read", "type"),
+            MESSAGE_COLUMNS,
             selection,
             null,
-            "date DESC LIMIT $limit"
+            "date DESC, _id DESC LIMIT $fetch OFFSET $skip"
         )
 
         cursor?.use { c ->
-            while (c.moveToNext()) {
-                messages.add(mapOf(
-                    "id"      to c.getLong(c.getColumnIndexOrThrow("_id")),
-                    "from"    to (c.getString(c.getColumnIndexOrThrow("address")) ?: ""),
-                    "body"    to (c.getString(c.getColumnIndexOrThrow("body")) ?: ""),
-                    "time"    to c.getLong(c.getColumnIndexOrThrow("date")),
-                    "read"    to (c.getInt(c.getColumnIndexOrThrow("read")) == 1),
-                    "type"    to when (c.getInt(c.getColumnIndexOrThrow("type"))) {
-                        1 -> "inbox"
-                        2 -> "sent"
-                        3 -> "draft"
-                        else -> "other"
-                    }
-                ))
-            }
+            while (c.moveToNext()) messages.add(messageRow(c))
+        }
+
+        if (includeMms) {
+            messages.addAll(Mms.list(context, unreadOnly, fromFilter, since, null, threadId, fetch))
+            messages = messages.sortedByDescending { it["time"] as Long }.drop(offset).take(limit).toMutableList()
         }
 
         return resultOk(cmd.id, mapOf("count" to messages.size, "messages" to messages))
     }
 
+    private fun sendMms(cmd: CmdMsg): String {
+        if (!hasSendSmsPermission()) return resultErr(cmd.id, "SEND_SMS permission not granted")
+
+        val recipients = cmd.flags["to"]?.split(",")?.map { it.trim() }?.filter { it.isNotEmpty() }
+            ?.takeIf { it.isNotEmpty() }
+            ?: return resultErr(cmd.id, "usage: sms send-mms <message> --to <n1,n2,...>")
+        val message = cmd.args.drop(1).joinToString(" ")
+
+        val attachments = cmd.payload?.let { payload ->
+            try {
+                val json = String(Base64.getDecoder().decode(payload))
+                val type = object : TypeToken<List<Map<String, String>>>() {}.type
+                gson.fromJson<List<Map<String, String>>>(json, type).map {
+                    Mms.Attachment(
+                        it["name"] ?: "attachment",
+                        it["mime"] ?: "application/octet-stream",
+                        Base64.getDecoder().decode(it["data"] ?: "")
+                    )
+                }
+            } catch (e: Exception) {
+                return resultErr(cmd.id, "invalid attachment payload: ${e.message}")
+            }
+        } ?: emptyList()
+        if (message.isEmpty() && attachments.isEmpty()) return resultErr(cmd.id, "message cannot be empty")
+
+        return try {
+            val result = Mms.send(context, recipients, message, attachments)
+            resultOk(cmd.id, mapOf(
+                "sent" to true,
+                "confirmed" to result.confirmed,
+                "to" to recipients,
+                "attachments" to attachments.size,
+                "bytes" to result.bytes
+            ))
+        } catch (e: Exception) {
+            resultErr(cmd.id, "MMS send failed: ${e.message}")
+        }
+    }
+
+    private fun attachment(cmd: CmdMsg): String {
+        if (!hasReadSmsPermission()) return resultErr(cmd.id, "READ_SMS permission not granted")
+
+        val partId = cmd.args.getOrNull(1)?.toLongOrNull()
+            ?: return resultErr(cmd.id, "usage: sms attachment <part-id>")
+        val (meta, bytes) = Mms.attachment(context, partId)
+            ?: return resultErr(cmd.id, "attachment not found: $partId")
+        return resultOk(cmd.id, meta + mapOf(
+            "size"     to bytes.size,
+            "content"  to Base64.getEncoder().encodeToString(bytes),
+            "encoding" to "base64"
+        ))
+    }
+
+    private fun search(cmd: CmdMsg): String {
+        if (!hasReadSmsPermission()) return resultErr(cmd.id, "READ_SMS permission not granted")
+
+        val query = cmd.args.drop(1).joinToString(" ")
+            .ifEmpty { return resultErr(cmd.id, "usage: sms search <query>") }
+        val regex = if (cmd.flags.containsKey("regex")) {
+            try { Regex(query) } catch (e: Exception) { return resultErr(cmd.id, "invalid regex: ${e.message}") }
+        } else null
+        val limit = cmd.flags["limit"]?.toIntOrNull() ?: 50
+        val contextLines = cmd.flags["context"]?.toIntOrNull() ?: 0
+
+        val clauses = mutableListOf<String>()
+        val args = mutableListOf<String>()
+        if (regex == null) {
+            // LIKE is case-insensitive for ASCII; escape the wildcards in the query
+            clauses.add("body LIKE ? ESCAPE '\\'")
+            args.add("%" + query.replace("\\", "\\\\").replace("%", "\\%").replace("_", "\\_") + "%")
+        }
+        cmd.flags["from"]?.let { clauses.add("address LIKE ?"); args.add("%$it%") }
+        cmd.flags["since"]?.toLongOrNull()?.let { clauses.add("date >= ?"); args.add(it.toString()) }
+
+        // Regex matching can't be expressed in SQL, so scan newest-first until
+        // enough bodies match; LIKE queries are limited by the provider.
+        val matches = mutableListOf<Map<String, Any?>>()
+        context.contentResolver.query(
+            Uri.parse("content://sms"),
+            MESSAGE_COLUMNS,
+            clauses.joinToString(" AND ").ifEmpty { null },
+            args.toTypedArray(),
+            if (regex == null) "date DESC, _id DESC LIMIT $limit" else "date DESC, _id DESC"
+        )?.use { c ->
+            while (matches.size < limit && c.moveToNext()) {
+                val row = messageRow(c)
+                if (regex != null && !regex.containsMatchIn(row["body"] as String)) continue
+                matches.add(row)
+            }
+        }
+
+        val results = if (contextLines > 0) matches.map { m ->
+            m + mapOf(
+                "before" to threadContext(m, contextLines, before = true),
+                "after"  to threadContext(m, contextLines, before = false)
+            )
+        } else matches
+
+        return resultOk(cmd.id, mapOf("count" to results.size, "messages" to results))
+    }
+
+    /** Up to [n] messages either side of [msg] in its thread, oldest first. */
+    private fun threadContext(msg: Map<String, Any?>, n: Int, before: Boolean): List<Map<String, Any?>> {
+        val date = msg["time"] as Long
+        val id = msg["id"] as Long
+        val out = mutableListOf<Map<String, Any?>>()
+        context.contentResolver.query(
+            Uri.parse("content://sms"),
+            MESSAGE_COLUMNS,
+            if (before) "thread_id = ? AND (date < ? OR (date = ? AND _id < ?))"
+            else "thread_id = ? AND (date > ? OR (date = ? AND _id > ?))",
+            arrayOf(msg["thread_id"].toString(), date.toString(), date.toString(), id.toString()),
+            if (before) "date DESC, _id DESC LIMIT $n" else "date ASC, _id ASC LIMIT $n"
+        )?.use { c ->
+            while (c.moveToNext()) out.add(messageRow(c))
+        }
+        return if (before) out.reversed() else out
+    }
+
     private fun send(cmd: CmdMsg): String {
         if (!hasSendSmsPermission()) return resultErr(cmd.id, "SEND_SMS permission not granted")
 
@@ -81,24 +230,11 @@ class SmsCommands(private val context: Context) {
             .ifEmpty { return resultErr(cmd.id, "message cannot be empty") }
 
         return try {
-            val smsManager = context.getSystemService(SmsManager::class.java)
-            val parts = smsManager.divideMessage(message)
-            smsManager.sendMultipartTextMessage(number, null, parts, null, null)
-
-            // Save to sent box
-            val values = ContentValues().apply {
-                put("address", number)
-                put("body", message)
-                put("type", 2) // sent
-                put("date", System.currentTimeMillis())
-                put("read", 1)
-            }
-            context.contentResolver.insert(Uri.parse("content://sms/sent"), values)
-
+            val parts = sendText(context, number, message)
             resultOk(cmd.id, mapOf(
                 "sent" to true,
                 "to" to number,
-                "parts" to parts.size,
+                "parts" to parts,
                 "message" to message
             ))
         } catch (e: Exception) {
@@ -119,8 +255,10 @@ class SmsCommands(private val context: Context) {
 
         cursor?.use { c ->
             while (c.moveToNext()) {
+                val threadId = c.getLong(c.getColumnIndexOrThrow("thread_id"))
                 convos.add(mapOf(
-                    "thread_id"  to c.getLong(c.getColumnIndexOrThrow("thread_id")),
+                    "thread_id"  to threadId,
+                    "address"    to threadAddress(threadId),
                     "snippet"    to (c.getString(c.getColumnIndexOrThrow("snippet")) ?: ""),
                     "date"       to c.getLong(c.getColumnIndexOrThrow("date")),
                     "msg_count"  to c.getInt(c.getColumnIndexOrThrow("msg_count"))
@@ -131,6 +269,211 @@ class SmsCommands(private val context: Context) {
         return resultOk(cmd.id, mapOf("conversations" to convos))
     }
 
+    private fun thread(cmd: CmdMsg): String {
+        if (!hasReadSmsPermission()) return resultErr(cmd.id, "READ_SMS permission not granted")
+
+        val target = cmd.args.drop(1).joinToString(" ").trim()
+            .ifEmpty { return resultErr(cmd.id, "usage: sms thread <thread:id|number|contact-name>") }
+        val threadId = resolveThread(target)
+            ?: return resultErr(cmd.id, "no conversation found for: $target")
+        val before = cmd.flags["before"]?.toLongOrNull()
+        // --before-kind and --before-id complete a (time, kind, id) cursor that
+        // breaks ties between messages stored at the same time
+        val beforeKind = cmd.flags["before-kind"] ?: "sms"
+        val beforeId = cmd.flags["before-id"]?.toLongOrNull()
+        val limit = cmd.flags["limit"]?.toIntOrNull() ?: 50
+
+        val selection = when {
+            before == null -> "thread_id = ?"
+            beforeId == null || beforeKind == "mms" -> "thread_id = ? AND date < ?"
+            else -> "thread_id = ? AND (date < ? OR (date = ? AND _id < ?))"
+        }
+        val selectionArgs = when {
+            before == null -> arrayOf(threadId.toString())
+            beforeId == null || beforeKind == "mms" -> arrayOf(threadId.toString(), before.toString())
+            else -> arrayOf(threadId.toString(), before.toString(), before.toString(), beforeId.toString())
+        }
+
+        // Fetch one extra row to know whether older messages exist
+        val messages = mutableListOf<Map<String, Any?>>()
+        context.contentResolver.query(
+            Uri.parse("content://sms"),
+            MESSAGE_COLUMNS,
+            selection,
+            selectionArgs,
+            "date DESC, _id DESC LIMIT ${limit + 1}"
+        )?.use { c ->
+            while (c.moveToNext()) messages.add(messageRow(c))
+        }
+        // Group conversations and picture messages live in the MMS table
+        messages.addAll(Mms.list(context, before = before, threadId = threadId, limit = limit + 1,
+            beforeKind = beforeKind, beforeId = beforeId))
+        messages.sortWith(pageOrder)
+        val hasMore = messages.size > limit
+        val page = messages.take(limit)
+
+        var markedRead = 0
+        if (cmd.flags.containsKey("mark-read")) {
+            // Only succeeds when PhoneSSH is the default SMS app; otherwise a no-op
+            markedRead = runCatching {
+                context.contentResolver.update(
+                    Uri.parse("content://sms/inbox"),
+                    ContentValues().apply { put("read", 1) },
+                    "thread_id = ? AND read = 0",
+                    arrayOf(threadId.toString())
+                )
+            }.getOrDefault(0)
+        }
+
+        return resultOk(cmd.id, mapOf(
+            "thread_id"   to threadId,
+            "address"     to (page.firstOrNull()?.get("from") ?: target),
+            "count"       to page.size,
+            "has_more"    to hasMore,
+            "marked_read" to markedRead,
+            "messages"    to page
+        ))
+    }
+
+    /**
+     * Restore messages from a backup. Android only lets the default SMS app
+     * write to the SMS provider, so this fails clearly otherwise. Messages that
+     * already exist (same address, date and body) are skipped.
+     */
+    private fun importMessages(cmd: CmdMsg): String {
+        if (!hasReadSmsPermission()) return resultErr(cmd.id, "READ_SMS permission not granted")
+        if (Telephony.Sms.getDefaultSmsPackage(context) != context.packageName) {
+            return resultErr(cmd.id, "restoring SMS requires PhoneSSH to be the default SMS app — set it in Settings > Apps > Default apps > SMS app, then switch back after importing")
+        }
+        val payload = cmd.payload ?: return resultErr(cmd.id, "no payload provided")
+
+        val json = String(Base64.getDecoder().decode(payload))
+        val type = object : TypeToken<List<Map<String, Any?>>>() {}.type
+        val rows: List<Map<String, Any?>> = gson.fromJson(json, type)
+
+        var inserted = 0
+        var skipped = 0
+        for (row in rows) {
+            val address = row["address"]?.toString() ?: continue
+            val body = row["body"]?.toString() ?: ""
+            val date = (row["date"] as? Number)?.toLong() ?: continue
+
+            val exists = context.contentResolver.query(
+                Uri.parse("content://sms"),
+                arrayOf("_id"),
+                "address = ? AND date = ? AND body = ?",
+                arrayOf(address, date.toString(), body),
+                null
+            )?.use { it.count > 0 } ?: false
+            if (exists) {
+                skipped++
+                continue
+            }
+
+            val values = ContentValues().apply {
+                put("address", address)
+                put("body", body)
+                put("date", date)
+                put("type", when (row["type"]?.toString()) {
+                    "sent" -> 2
+                    "draft" -> 3
+                    "outbox" -> 4
+                    "failed" -> 5
+                    "queued" -> 6
+                    else -> 1
+                })
+                put("read", if (row["read"] == false) 0 else 1)
+            }
+            if (context.contentResolver.insert(Uri.parse("content://sms"), values) != null) inserted++
+        }
+
+        return resultOk(cmd.id, mapOf("inserted" to inserted, "skipped" to skipped, "received" to rows.size))
+    }
+
+    /**
+     * Resolve "thread:<id>", a phone number or short code, or a contact name to
+     * a thread ID. Long numbers are matched on their trailing digits so
+     * "+1 555-123-4567" and "5551234567" agree; short codes must match exactly.
+     * A bare number with no conversation is tried as a thread ID last.
+     */
+    private fun resolveThread(target: String): Long? {
+        if (target.startsWith("thread:", ignoreCase = true)) {
+            return target.substringAfter(':').trim().toLongOrNull()
+        }
+
+        val isNumber = target.any { it.isDigit() } && target.none { it.isLetter() }
+        val numbers = if (isNumber) listOf(target) else lookupContactNumbers(target)
+        for (number in numbers) {
+            threadForNumber(number)?.let { return it }
+        }
+
+        if (target.all { it.isDigit() }) {
+            return target.toLongOrNull()?.takeIf { threadAddress(it).isNotEmpty() }
+        }
+        return null
+    }
+
+    private fun threadForNumber(number: String): Long? {
+        val digits = number.filter { it.isDigit() }
+        if (digits.isEmpty()) return null
+        val (selection, arg) = if (digits.length < 7) {
+            "address = ?" to digits
+        } else {
+            "address LIKE ?" to "%${digits.takeLast(9)}"
+        }
+        return context.contentResolver.query(
+            Uri.parse("content://sms"),
+            arrayOf("thread_id"),
+            selection,
+            arrayOf(arg),
+            "date DESC LIMIT 1"
+        )?.use { c -> if (c.moveToFirst()) c.getLong(0) else null }
+    }
+
+    private fun threadAddress(threadId: Long): String =
+        context.contentResolver.query(
+            Uri.parse("content://sms"),
+            arrayOf("address"),
+            "thread_id = ?",
+            arrayOf(threadId.toString()),
+            "date DESC LIMIT 1"
+        )?.use { c -> if (c.moveToFirst()) c.getString(0) else null } ?: ""
+
+    private fun lookupContactNumbers(name: String): List<String> {
+        if (ActivityCompat.checkSelfPermission(context, Manifest.permission.READ_CONTACTS) !=
+            PackageManager.PERMISSION_GRANTED) return emptyList()
+
+        val numbers = mutableListOf<String>()
+        context.contentResolver.query(
+            ContactsContract.CommonDataKinds.Phone.CONTENT_URI,
+            arrayOf(ContactsContract.CommonDataKinds.Phone.NUMBER),
+            "${ContactsContract.CommonDataKinds.Phone.DISPLAY_NAME} LIKE ?",
+            arrayOf("%$name%"),
+            null
+        )?.use { c ->
+            while (c.moveToNext()) c.getString(0)?.let { numbers.add(it) }
+        }
+        return numbers
+    }
+
+    private fun messageRow(c: Cursor): Map<String, Any?> = mapOf(
+        "id"        to c.getLong(c.getColumnIndexOrThrow("_id")),
+        "thread_id" to c.getLong(c.getColumnIndexOrThrow("thread_id")),
+        "from"      to (c.getString(c.getColumnIndexOrThrow("address")) ?: ""),
+        "body"      to (c.getString(c.getColumnIndexOrThrow("body")) ?: ""),
+        "time"      to c.getLong(c.getColumnIndexOrThrow("date")),
+        "read"      to (c.getInt(c.getColumnIndexOrThrow("read")) == 1),
+        "type"      to when (c.getInt(c.getColumnIndexOrThrow("type"))) {
+            1 -> "inbox"
+            2 -> "sent"
+            3 -> "draft"
+            4 -> "outbox"
+            5 -> "failed"
+            6 -> "queued"
+            else -> "other"
+        }
+    )
+
     private fun hasReadSmsPermission() =
         ActivityCompat.checkSelfPermission(context, Manifest.permission.READ_SMS) ==
                 PackageManager.PERMISSION_GRANTED
@@ -138,4 +481,55 @@ class SmsCommands(private val context: Context) {
     private fun hasSendSmsPermission() =
         ActivityCompat.checkSelfPermission(context, Manifest.permission.SEND_SMS) ==
                 PackageManager.PERMISSION_GRANTED
+
+    private fun schedule(cmd: CmdMsg): String {
+        if (!hasSendSmsPermission()) return resultErr(cmd.id, "SEND_SMS permission not granted")
+
+        val number = cmd.args.getOrNull(1) ?: return resultErr(cmd.id, "usage: sms schedule <number> <message> --at <epoch-ms>")
+        val message = cmd.args.drop(2).joinToString(" ")
+            .ifEmpty { return resultErr(cmd.id, "message cannot be empty") }
+        val at = cmd.flags["at"]?.toLongOrNull()
+            ?: return resultErr(cmd.id, "--at <epoch-ms> is required")
+        if (at <= System.currentTimeMillis()) return resultErr(cmd.id, "--at is in the past")
+
+        val entry = ScheduledSms.add(context, number, message, at)
+        return resultOk(cmd.id, entry)
+    }
+
+    private fun unschedule(cmd: CmdMsg): String {
+        val id = cmd.args.getOrNull(1)?.toLongOrNull()
+            ?: return resultErr(cmd.id, "usage: sms unschedule <id>")
+        return if (ScheduledSms.cancel(context, id)) resultOk(cmd.id, mapOf("cancelled" to id))
+        else resultErr(cmd.id, "no pending scheduled message with id $id")
+    }
+
+    companion object {
+        /**
+         * Newest first by (time, kind, id): SMS and MMS ids come from different
+         * tables, so at the same time every SMS sorts before every MMS.
+         */
+        private val pageOrder = compareByDescending<Map<String, Any?>> { it["time"] as Long }
+            .thenByDescending { if (it["kind"] == "mms") 0 else 1 }
+            .thenByDescending { it["id"] as Long }
+
+        private val MESSAGE_COLUMNS = arrayOf("_id", "thread_id", "address", "body", "date", "read", "type")
+
+        /** Sends [message] to [number] and records it in the sent box. Returns the part count. */
+        fun sendText(context: Context, number: String, message: String): Int {
+            val smsManager = context.getSystemService(SmsManager::class.java)
+            val parts = smsManager.divideMessage(message)
+            smsManager.sendMultipartTextMessage(number, null, parts, null, null)
+
+            // Save to sent box
+            val values = ContentValues().apply {
+                put("address", number)
+                put("body", message)
+                put("type", 2) // sent
+                put("date", System.currentTimeMillis())
+                put("read", 1)
+            }
+            context.contentResolver.insert(Uri.parse("content://sms/sent"), values)
+            return parts.size
+        }
+    }
 }
@@ -36,11 +36,18 @@ class SystemCommands(private val context: Context) {
                 "model" to Build.MODEL,
                 "manufacturer" to Build.MANUFACTURER,
                 "android" to Build.VERSION.RELEASE,
-                "sdk" to Build.VERSION.SDK_INT
+                "sdk" to Build.VERSION.SDK_INT,
+                // For picking the matching splits of an app bundle
+                "abis" to Build.SUPPORTED_ABIS.toList(),
+                "density" to context.resources.displayMetrics.densityDpi,
+                "languages" to context.resources.configuration.locales.let { locales ->
+                    (0 until locales.size()).map { locales[it].language }.distinct()
+                }
             ),
             "battery" to battery,
             "storage" to storage,
-            "wifi" to wifi
+            "wifi" to wifi,
+            "call" to CallCommands.callState(context)
         ))
     }
 
@@ -103,12 +103,27 @@ class UiCommands(private val context: Context) {
     }
 
     /**
-     * psh ui dump
+     * psh ui dump [--tree]
      * Dumps all interactive/labelled UI elements in the current window.
+     * With --tree every node is returned with its resource id, state and
+     * parent index, for client-side selector matching.
      */
     fun ui(cmd: CmdMsg): String {
         val sub = cmd.args.firstOrNull() ?: "dump"
-        if (sub != "dump") return resultErr(cmd.id, "usage: ui dump")
+        if (sub != "dump") return resultErr(cmd.id, "usage: ui dump [--tree]")
+
+        if (cmd.flags.containsKey("tree")) {
+            if (PshAccessibilityService.instance == null) {
+                return resultErr(cmd.id, "accessibility service not running — enable PhoneSSH in Settings → Accessibility")
+            }
+            // Empty while windows change; the CLI retries this one
+            val nodes = PshAccessibilityService.dumpTree()
+            if (nodes.isEmpty()) return resultErr(cmd.id, "no active window")
+            return resultOk(cmd.id, mapOf(
+                "count" to nodes.size,
+                "nodes" to nodes
+            ))
+        }
 
         val elements = PshAccessibilityService.dumpElements()
         return resultOk(cmd.id, mapOf(
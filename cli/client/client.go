@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -67,7 +68,7 @@ type ResultMsg struct {
 
 // Connect dials the daemon, performs auth handshake, and returns a ready Client.
 func Connect(device *Device) (*Client, error) {
-	addr := fmt.Sprintf("%s:%d", device.Host, device.Port)
+	addr := net.JoinHostPort(device.Host, strconv.Itoa(device.Port))
 
 	conn, err := net.DialTimeout("tcp", addr, DialTimeout)
 	if err != nil {
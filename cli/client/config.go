@@ -21,6 +21,10 @@ type Config struct {
 	Devices       []Device `json:"devices"`
 	DefaultDevice string   `json:"default_device"`
 	AnthropicKey  string   `json:"anthropic_key,omitempty"`
+
+	// AppAliases maps names chosen in the app picker (or set with
+	// 'psh apps alias') to package names.
+	AppAliases map[string]string `json:"app_aliases,omitempty"`
 }
 
 func ConfigDir() (string, error) {
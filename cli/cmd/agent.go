@@ -0,0 +1,359 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/phonessh/psh/client"
+	"github.com/phonessh/psh/history"
+	"github.com/phonessh/psh/rules"
+	"github.com/spf13/cobra"
+)
+
+var agentCmd = &cobra.Command{
+	Use:   "agent",
+	Short: "Run the notification rule engine and scheduled SMS in the foreground",
+	Long: `Run a long-lived agent that polls the phone for new notifications and
+runs the actions of every matching rule. New notifications are also archived
+to the local history (see 'psh notifs history').
+
+Rules live in rules.yaml (or rules.json) in the psh config dir:
+
+  rules:
+    - name: pagerduty-critical
+      app: pagerduty              # substring of the package name
+      title: "(?i)critical"       # regex on the title (optional)
+      text: ""                    # regex on the text (optional)
+      cooldown: 5m                # don't re-fire within this window
+      actions:
+        - psh: volume set 100 --stream ring
+        - psh: dnd off
+        - shell: notify-send "$PSH_TITLE" "$PSH_TEXT"
+        - webhook:
+            url: http://localhost:9000/hook
+            body: '{"app": {{json .App}}, "title": {{json .Title}}, "text": {{json .Text}}}'
+
+psh and webhook fields are Go templates over {{.App}}, {{.Title}}, {{.Text}},
+{{.Key}} and {{.Time}}. A psh command is split into words before templates
+are filled in, so each value stays one argument; quote a template that
+contains spaces. Use {{json .Text}} to insert a value as a quoted,
+escaped JSON string, e.g. in webhook bodies. Shell commands receive the same values as the
+environment variables PSH_APP, PSH_TITLE, PSH_TEXT and PSH_KEY.
+
+The rules file is re-read whenever it changes.
+
+The agent also sends SMS scheduled with 'psh sms send --at/--in' (without
+--on-phone) when their time comes; it runs without a rules file for that.
+
+Examples:
+  psh agent
+  psh agent --check
+  psh agent --dry-run --interval 5s`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		interval, _ := cmd.Flags().GetDuration("interval")
+		rulesPath, _ := cmd.Flags().GetString("rules")
+		check, _ := cmd.Flags().GetBool("check")
+		noHistory, _ := cmd.Flags().GetBool("no-history")
+
+		if rulesPath == "" {
+			p, err := rules.Path()
+			if err != nil {
+				return err
+			}
+			rulesPath = p
+		}
+
+		a := &agent{
+			rulesPath:    rulesPath,
+			lastFired:    map[string]time.Time{},
+			requireRules: check,
+		}
+		a.dryRun, _ = cmd.Flags().GetBool("dry-run")
+		a.fireExisting, _ = cmd.Flags().GetBool("fire-existing")
+		a.smsMaxLate, _ = cmd.Flags().GetDuration("sms-max-late")
+
+		if err := a.reloadRules(); err != nil {
+			return err
+		}
+		if check {
+			for _, r := range a.rules.Rules {
+				bold.Printf("%s\n", r.Name)
+				for i, act := range r.Actions {
+					dim.Printf("  → %s\n", act.Describe())
+					if act.Psh == "" {
+						continue
+					}
+					// Render against an empty notification to catch template errors
+					if _, _, _, err := pshCommand(act.Psh, rules.Event{}); err != nil {
+						return fmt.Errorf("rule %q: action %d: %w", r.Name, i+1, err)
+					}
+				}
+			}
+			green.Printf("%d rule(s) OK in %s\n", len(a.rules.Rules), rulesPath)
+			return nil
+		}
+
+		// Rules run unattended: an app name that is not exact must fail
+		// rather than stop the poll loop at a prompt
+		flagNoInput = true
+
+		if !noHistory {
+			store, err := history.Open()
+			if err != nil {
+				return err
+			}
+			defer store.Close()
+			a.store = store
+		}
+
+		dim.Printf("psh agent: %d rule(s) from %s, polling every %s (Ctrl+C to stop)\n",
+			len(a.rules.Rules), rulesPath, interval)
+		for {
+			if err := a.reloadRules(); err != nil {
+				red.Fprintf(os.Stderr, "%s  %v (keeping previous rules)\n", time.Now().Format("15:04:05"), err)
+			}
+			if err := a.poll(); err != nil {
+				red.Fprintf(os.Stderr, "%s  poll failed: %v\n", time.Now().Format("15:04:05"), err)
+			}
+			time.Sleep(interval)
+		}
+	},
+}
+
+func init() {
+	agentCmd.Flags().Duration("interval", 10*time.Second, "how often to poll the phone")
+	agentCmd.Flags().String("rules", "", "rules file (default: rules.yaml in the config dir)")
+	agentCmd.Flags().Bool("check", false, "validate the rules file and exit")
+	agentCmd.Flags().Bool("dry-run", false, "log matching actions without running them")
+	agentCmd.Flags().Bool("fire-existing", false, "also evaluate notifications already present at startup")
+	agentCmd.Flags().Bool("no-history", false, "don't archive notifications to the local history")
+	agentCmd.Flags().Duration("sms-max-late", 6*time.Hour, "skip scheduled SMS that are overdue by more than this (0 = always send)")
+}
+
+type agent struct {
+	rulesPath    string
+	rules        *rules.File
+	rulesMod     time.Time
+	store        *history.Store
+	dryRun       bool
+	fireExisting bool
+	requireRules bool
+	smsMaxLate   time.Duration
+
+	primed    bool
+	seen      map[string]bool
+	lastFired map[string]time.Time
+}
+
+// reloadRules (re)loads the rules file if it changed since the last load.
+// A missing file is an error only on first load with --check.
+func (a *agent) reloadRules() error {
+	info, err := os.Stat(a.rulesPath)
+	if err != nil {
+		if a.rules != nil {
+			return nil
+		}
+		if !a.requireRules {
+			// Nothing to evaluate yet, but scheduled SMS still go out
+			a.rules = &rules.File{}
+			return nil
+		}
+		return fmt.Errorf("no rules file at %s — see 'psh agent --help' for the format", a.rulesPath)
+	}
+	if a.rules != nil && !info.ModTime().After(a.rulesMod) {
+		return nil
+	}
+
+	f, err := rules.Load(a.rulesPath)
+	if err != nil {
+		return err
+	}
+	if a.rules != nil {
+		dim.Printf("%s  reloaded %d rule(s)\n", time.Now().Format("15:04:05"), len(f.Rules))
+	}
+	a.rules = f
+	a.rulesMod = info.ModTime()
+	return nil
+}
+
+// poll sends due scheduled SMS, then fetches current notifications and
+// evaluates rules against new ones. The first poll only records what is
+// already on the phone unless --fire-existing is set.
+func (a *agent) poll() error {
+	c, dev, err := getClient()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if err := sendDueSMS(c, dev, a.smsMaxLate, a.dryRun); err != nil {
+		red.Fprintf(os.Stderr, "  scheduled SMS: %v\n", err)
+	}
+
+	data, err := c.RunRaw(newCmd("notifs", nil, map[string]string{"limit": "200"}))
+	if err != nil {
+		return err
+	}
+	notifs := notifsFromData(dev.Name, data)
+
+	if a.store != nil {
+		if _, err := a.store.AddNotifications(notifs); err != nil {
+			red.Fprintf(os.Stderr, "  history: %v\n", err)
+		}
+	}
+
+	current := make(map[string]bool, len(notifs))
+	// Oldest first so actions fire in the order notifications arrived.
+	for i := len(notifs) - 1; i >= 0; i-- {
+		n := notifs[i]
+		id := n.Key + "@" + strconv.FormatInt(n.Posted.UnixMilli(), 10)
+		current[id] = true
+		if a.seen[id] || (!a.primed && !a.fireExisting) {
+			continue
+		}
+		a.evaluate(c, rules.Event{
+			Key:   n.Key,
+			App:   n.App,
+			Title: n.Title,
+			Text:  n.Text,
+			Time:  n.Posted,
+		})
+	}
+	a.seen = current
+	a.primed = true
+	return nil
+}
+
+func (a *agent) evaluate(c *client.Client, e rules.Event) {
+	for _, r := range a.rules.Rules {
+		if !r.Matches(e) {
+			continue
+		}
+		if last, ok := a.lastFired[r.Name]; ok && time.Since(last) < r.CooldownPeriod() {
+			dim.Printf("%s  %s matched %s (cooling down)\n", time.Now().Format("15:04:05"), r.Name, e.App)
+			continue
+		}
+		a.lastFired[r.Name] = time.Now()
+
+		cyan.Printf("%s  %s", time.Now().Format("15:04:05"), r.Name)
+		dim.Printf("  %s: %s\n", e.App, e.Title)
+		for _, act := range r.Actions {
+			fmt.Printf("  → %s\n", act.Describe())
+			if a.dryRun {
+				continue
+			}
+			if err := runAction(c, act, e); err != nil {
+				red.Printf("    error: %v\n", err)
+			}
+		}
+	}
+}
+
+func runAction(c *client.Client, act rules.Action, e rules.Event) error {
+	switch {
+	case act.Psh != "":
+		name, pureArgs, flags, err := pshCommand(act.Psh, e)
+		if err != nil {
+			return err
+		}
+		if pureArgs, err = resolveAppArgs(c, name, pureArgs); err != nil {
+			return err
+		}
+		_, err = c.RunRaw(newCmd(name, pureArgs, flags))
+		return err
+
+	case act.Shell != "":
+		var sh *exec.Cmd
+		if runtime.GOOS == "windows" {
+			sh = exec.Command("cmd", "/C", act.Shell)
+		} else {
+			sh = exec.Command("sh", "-c", act.Shell)
+		}
+		sh.Env = append(os.Environ(),
+			"PSH_APP="+e.App,
+			"PSH_TITLE="+e.Title,
+			"PSH_TEXT="+e.Text,
+			"PSH_KEY="+e.Key,
+		)
+		out, err := sh.CombinedOutput()
+		if s := strings.TrimSpace(string(out)); s != "" {
+			dim.Printf("    %s\n", strings.ReplaceAll(s, "\n", "\n    "))
+		}
+		return err
+
+	case act.Webhook != nil:
+		return fireWebhook(act.Webhook, e)
+	}
+	return nil
+}
+
+// pshCommand splits a psh: action into command, args and flags first and
+// then expands the templates in each arg and flag value on its own, so
+// notification text can never add arguments or flags of its own.
+func pshCommand(line string, e rules.Event) (string, []string, map[string]string, error) {
+	parts := parseCommand(line)
+	if len(parts) > 0 && parts[0] == "psh" {
+		parts = parts[1:]
+	}
+	if len(parts) == 0 {
+		return "", nil, nil, fmt.Errorf("empty psh command")
+	}
+	pureArgs, flags := splitFlags(parts[1:])
+	var err error
+	for i, a := range pureArgs {
+		if pureArgs[i], err = rules.Expand(a, e); err != nil {
+			return "", nil, nil, fmt.Errorf("argument %q: %w", a, err)
+		}
+	}
+	for k, v := range flags {
+		if flags[k], err = rules.Expand(v, e); err != nil {
+			return "", nil, nil, fmt.Errorf("--%s %q: %w", k, v, err)
+		}
+	}
+	return parts[0], pureArgs, flags, nil
+}
+
+func fireWebhook(wh *rules.Webhook, e rules.Event) error {
+	url, err := rules.Expand(wh.URL, e)
+	if err != nil {
+		return err
+	}
+	body, err := rules.Expand(wh.Body, e)
+	if err != nil {
+		return err
+	}
+	method := strings.ToUpper(wh.Method)
+	if method == "" {
+		method = http.MethodPost
+	}
+
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range wh.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := (&http.Client{Timeout: 10 * time.Second}).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/phonessh/psh/client"
+	"github.com/phonessh/psh/selector"
 	"github.com/spf13/cobra"
 )
 
@@ -75,7 +76,11 @@ Available commands:
 - psh clipboard get
 - psh clipboard set "<text>"
 - psh ui dump
+- psh ui find <selector>
+- psh ui wait-for <text-or-selector> [--gone] [--timeout 10s]
+- psh ui wait-idle
 - psh click <text-or-description>
+- psh click "resource-id=<id>" / psh click 'class=Button && text~="^Sub"'
 - psh open <url-or-deep-link>
 - psh tap <x> <y>
 - psh swipe <x1> <y1> <x2> <y2> [--duration <ms>]
@@ -404,26 +409,17 @@ func executeCommands(c *client.Client, commands []string, _ interface{}) (screen
 		subCmd := parts[1]
 		cmdArgs := parts[2:]
 
-		flags := map[string]string{}
-		pureArgs := []string{}
-		i := 0
-		for i < len(cmdArgs) {
-			if strings.HasPrefix(cmdArgs[i], "--") {
-				key := strings.TrimPrefix(cmdArgs[i], "--")
-				if i+1 < len(cmdArgs) && !strings.HasPrefix(cmdArgs[i+1], "--") {
-					flags[key] = cmdArgs[i+1]
-					i += 2
-				} else {
-					flags[key] = "true"
-					i++
-				}
-			} else {
-				pureArgs = append(pureArgs, cmdArgs[i])
-				i++
-			}
-		}
+		pureArgs, flags := splitFlags(cmdArgs)
 
 		cyan.Printf("→ %s\n", rawCmd)
+		if runLocalUICommand(c, subCmd, pureArgs, flags) {
+			continue
+		}
+		pureArgs, err := resolveAppArgs(c, subCmd, pureArgs)
+		if err != nil {
+			red.Printf("  error: %v\n", err)
+			continue
+		}
 		msg := client.CmdMsg{
 			Type:  "cmd",
 			ID:    fmt.Sprintf("%d", time.Now().UnixNano()),
@@ -463,6 +459,80 @@ func executeCommands(c *client.Client, commands []string, _ interface{}) (screen
 	return
 }
 
+// runLocalUICommand runs the UI commands whose logic lives in the CLI
+// rather than the daemon: selector clicks, 'ui find' and the 'ui wait-*'
+// polling loops. It reports whether the command was handled.
+func runLocalUICommand(c *client.Client, subCmd string, args []string, flags map[string]string) bool {
+	switch {
+	case subCmd == "click":
+		sel, err := selector.Parse(strings.Join(args, " "))
+		if err != nil {
+			red.Printf("  error: %v\n", err)
+			return true
+		}
+		if _, plain := sel.(selector.Text); plain {
+			return false
+		}
+		n, _, err := findOnScreen(c, sel)
+		if err == nil {
+			var x, y int
+			if x, y, err = tapNode(c, n); err == nil {
+				green.Printf("  clicked: %s at (%d, %d)\n", nodeName(n), x, y)
+			}
+		}
+		if err != nil {
+			red.Printf("  error: %v\n", err)
+		}
+		return true
+
+	case subCmd == "ui" && len(args) > 1 && args[0] == "find":
+		sel, err := selector.Parse(strings.Join(args[1:], " "))
+		if err != nil {
+			red.Printf("  error: %v\n", err)
+			return true
+		}
+		tree, err := dumpTree(c)
+		if err != nil {
+			red.Printf("  error: %v\n", err)
+			return true
+		}
+		nodes := sel.Find(tree)
+		for _, n := range nodes {
+			x, y := n.Bounds.Center()
+			fmt.Printf("  %s %s (%d,%d)\n", n.ShortClass(), nodeName(n), x, y)
+		}
+		fmt.Printf("  %d match(es)\n", len(nodes))
+		return true
+
+	case subCmd == "ui" && len(args) > 0 && (args[0] == "wait-for" || args[0] == "wait-idle"):
+		timeout := 10 * time.Second
+		if t, ok := flags["timeout"]; ok {
+			d, err := time.ParseDuration(t)
+			if err != nil {
+				red.Printf("  error: invalid --timeout: %v\n", err)
+				return true
+			}
+			timeout = d
+		}
+		var err error
+		if args[0] == "wait-idle" {
+			err = waitIdle(c, timeout, 300*time.Millisecond)
+		} else {
+			var sel selector.Selector
+			if sel, err = selector.Parse(strings.Join(args[1:], " ")); err == nil {
+				_, err = waitForSelector(c, sel, flags["gone"] == "true", timeout, 300*time.Millisecond)
+			}
+		}
+		if err != nil {
+			red.Printf("  error: %v\n", err)
+		} else {
+			green.Println("  done")
+		}
+		return true
+	}
+	return false
+}
+
 // ── Helpers ───────────────────────────────────────────────────────────────────
 
 func parseLines(text string) []string {
@@ -476,6 +546,29 @@ func parseLines(text string) []string {
 	return out
 }
 
+// splitFlags separates "--key value" / "--key" pairs from positional args.
+func splitFlags(cmdArgs []string) ([]string, map[string]string) {
+	flags := map[string]string{}
+	pureArgs := []string{}
+	i := 0
+	for i < len(cmdArgs) {
+		if strings.HasPrefix(cmdArgs[i], "--") {
+			key := strings.TrimPrefix(cmdArgs[i], "--")
+			if i+1 < len(cmdArgs) && !strings.HasPrefix(cmdArgs[i+1], "--") {
+				flags[key] = cmdArgs[i+1]
+				i += 2
+			} else {
+				flags[key] = "true"
+				i++
+			}
+		} else {
+			pureArgs = append(pureArgs, cmdArgs[i])
+			i++
+		}
+	}
+	return pureArgs, flags
+}
+
 func parseCommand(raw string) []string {
 	var parts []string
 	var current strings.Builder
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"text/tabwriter"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
 )
@@ -20,7 +21,12 @@ Examples:
   psh apps launch spotify        Open Spotify
   psh apps kill twitter          Kill Twitter in background
   psh apps info com.spotify.music  App details
-  psh apps install /sdcard/app.apk Sideload an APK`,
+  psh apps install ./app.apk     Upload and install an APK
+
+App names are used as is when they are an exact label or package name;
+otherwise psh lists the closest installed apps, asks which one you mean and
+remembers the answer (see 'psh apps alias'). Pass --no-input to fail
+instead of asking.`,
 }
 
 var appsListCmd = &cobra.Command{
@@ -78,7 +84,11 @@ var appsLaunchCmd = &cobra.Command{
 		c, _ := mustConnect()
 		defer c.Close()
 
-		data, err := c.RunRaw(newCmd("apps", []string{"launch", args[0]}, nil))
+		pkg, _, err := resolveApp(c, args[0])
+		if err != nil {
+			return err
+		}
+		data, err := c.RunRaw(newCmd("apps", []string{"launch", pkg}, nil))
 		if err != nil {
 			return err
 		}
@@ -95,7 +105,11 @@ var appsKillCmd = &cobra.Command{
 		c, _ := mustConnect()
 		defer c.Close()
 
-		data, err := c.RunRaw(newCmd("apps", []string{"kill", args[0]}, nil))
+		pkg, _, err := resolveApp(c, args[0])
+		if err != nil {
+			return err
+		}
+		data, err := c.RunRaw(newCmd("apps", []string{"kill", pkg}, nil))
 		if err != nil {
 			return err
 		}
@@ -115,7 +129,11 @@ var appsInfoCmd = &cobra.Command{
 		c, _ := mustConnect()
 		defer c.Close()
 
-		data, err := c.RunRaw(newCmd("apps", []string{"info", args[0]}, nil))
+		pkg, _, err := resolveApp(c, args[0])
+		if err != nil {
+			return err
+		}
+		data, err := c.RunRaw(newCmd("apps", []string{"info", pkg}, nil))
 		if err != nil {
 			return err
 		}
@@ -131,13 +149,33 @@ var appsInfoCmd = &cobra.Command{
 }
 
 var appsInstallCmd = &cobra.Command{
-	Use:   "install <remote-apk-path>",
-	Short: "Install an APK from the phone's storage",
-	Args:  cobra.ExactArgs(1),
+	Use:   "install <local-apk|apks|xapk|remote-apk-path>",
+	Short: "Install an APK from this computer or the phone's storage",
+	Long: `Install an app.
+
+A file on this computer (.apk, or an .apks / .xapk split bundle) is uploaded,
+installed in one step and the result is reported with the installed version.
+From a bundle only the splits for the phone's ABI, screen density and
+languages are installed (or universal.apk, when the bundle has one).
+Android may ask for confirmation on the phone the first time.
+
+Any other path is treated as an APK already on the phone, and the system
+install prompt is opened for it.
+
+Examples:
+  psh apps install ./build/app-debug.apk
+  psh apps install ./app.apks
+  psh apps install /sdcard/Download/app.apk`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		c, _ := mustConnect()
 		defer c.Close()
 
+		if info, err := os.Stat(args[0]); err == nil && !info.IsDir() {
+			timeout, _ := cmd.Flags().GetDuration("timeout")
+			return installLocal(c, args[0], timeout)
+		}
+
 		data, err := c.RunRaw(newCmd("apps", []string{"install", args[0]}, nil))
 		if err != nil {
 			return err
@@ -151,14 +189,22 @@ var appsInstallCmd = &cobra.Command{
 }
 
 var appsUninstallCmd = &cobra.Command{
-	Use:   "uninstall <package>",
+	Use:   "uninstall <name-or-package>",
 	Short: "Uninstall an app",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		c, _ := mustConnect()
 		defer c.Close()
 
-		data, err := c.RunRaw(newCmd("apps", []string{"uninstall", args[0]}, nil))
+		pkg, label, err := resolveApp(c, args[0])
+		if err != nil {
+			return err
+		}
+		yes, _ := cmd.Flags().GetBool("yes")
+		if ok, err := confirmAction(yes, "uninstall", fmt.Sprintf("Uninstall %s (%s)?", label, pkg)); !ok {
+			return err
+		}
+		data, err := c.RunRaw(newCmd("apps", []string{"uninstall", pkg}, nil))
 		if err != nil {
 			return err
 		}
@@ -170,6 +216,8 @@ var appsUninstallCmd = &cobra.Command{
 func init() {
 	appsListCmd.Flags().Bool("system", false, "include system apps")
 	appsListCmd.Flags().String("filter", "", "filter by name or package")
+	appsInstallCmd.Flags().Duration("timeout", 3*time.Minute, "how long to wait for the install result")
+	appsUninstallCmd.Flags().BoolP("yes", "y", false, "skip the confirmation prompt")
 
 	appsCmd.AddCommand(appsListCmd)
 	appsCmd.AddCommand(appsLaunchCmd)
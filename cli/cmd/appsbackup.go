@@ -0,0 +1,272 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/phonessh/psh/client"
+	"github.com/spf13/cobra"
+)
+
+// appRecord is the version and install metadata of one app, as returned by
+// the daemon's 'apps info'.
+type appRecord struct {
+	Package     string    `json:"package"`
+	Name        string    `json:"name"`
+	VersionName string    `json:"version_name"`
+	VersionCode int64     `json:"version_code"`
+	Enabled     bool      `json:"enabled"`
+	System      bool      `json:"system"`
+	Installed   time.Time `json:"installed"`
+	Updated     time.Time `json:"updated"`
+}
+
+func appRecordFromData(data map[string]interface{}) appRecord {
+	num := func(k string) int64 {
+		v, _ := data[k].(float64)
+		return int64(v)
+	}
+	enabled, _ := data["enabled"].(bool)
+	system, _ := data["system"].(bool)
+	return appRecord{
+		Package:     str(data["package"]),
+		Name:        str(data["name"]),
+		VersionName: str(data["version_name"]),
+		VersionCode: num("version_code"),
+		Enabled:     enabled,
+		System:      system,
+		Installed:   time.UnixMilli(num("installed")),
+		Updated:     time.UnixMilli(num("updated")),
+	}
+}
+
+// appBackupMeta is written as metadata.json next to the backed-up APKs.
+type appBackupMeta struct {
+	appRecord
+	APKs     []string  `json:"apks"`
+	Device   string    `json:"device"`
+	BackedUp time.Time `json:"backed_up"`
+}
+
+var appsBackupCmd = &cobra.Command{
+	Use:   "backup <name-or-package>... <local-dir> | --all-user <local-dir>",
+	Short: "Save app APKs (base + splits) and version metadata to this computer",
+	Long: `Pull each app's base and split APKs plus a metadata.json (package,
+version, install and update times) into <local-dir>/<package>-<version-code>/.
+
+Existing backups of the same version are skipped, so the directory works as
+an archive of known-good versions. Restore with 'psh apps restore'.
+
+Only the APKs are saved — not the app's data.
+
+Examples:
+  psh apps backup spotify ./apk-archive
+  psh apps backup com.example.app com.example.other ./apk-archive
+  psh apps backup --all-user ./apk-archive`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		allUser, _ := cmd.Flags().GetBool("all-user")
+		force, _ := cmd.Flags().GetBool("force")
+		dir := args[len(args)-1]
+		targets := args[:len(args)-1]
+		if allUser == (len(targets) > 0) {
+			return fmt.Errorf("give app names or --all-user, followed by the local directory")
+		}
+
+		c, dev := mustConnect()
+		defer c.Close()
+
+		if allUser {
+			data, err := c.RunRaw(newCmd("apps", []string{"list"}, nil))
+			if err != nil {
+				return err
+			}
+			apps, _ := data["apps"].([]interface{})
+			for _, a := range apps {
+				if app, ok := a.(map[string]interface{}); ok {
+					targets = append(targets, str(app["package"]))
+				}
+			}
+		}
+
+		failed := 0
+		for _, target := range targets {
+			pkg := target
+			var err error
+			if !allUser {
+				pkg, _, err = resolveApp(c, target)
+			}
+			if err == nil {
+				err = backupApp(c, dev, pkg, dir, force)
+			}
+			if err != nil {
+				red.Printf("  ✗ %s: %v\n", target, err)
+				failed++
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d app(s) failed", failed, len(targets))
+		}
+		return nil
+	},
+}
+
+var appsRestoreCmd = &cobra.Command{
+	Use:   "restore <backup-dir>",
+	Short: "Reinstall apps saved with 'psh apps backup'",
+	Long: `Reinstall apps from a backup made with 'psh apps backup'. <backup-dir> is
+either one app's backup (containing metadata.json) or an archive directory,
+in which case every app backup inside it is restored.
+
+Installing an older version over a newer one is refused by Android; uninstall
+the app first to roll back.
+
+Examples:
+  psh apps restore ./apk-archive/com.example.app-42
+  psh apps restore ./apk-archive`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+
+		dirs, err := backupDirs(args[0])
+		if err != nil {
+			return err
+		}
+
+		c, _ := mustConnect()
+		defer c.Close()
+
+		failed := 0
+		for _, d := range dirs {
+			if err := restoreApp(c, d, timeout); err != nil {
+				red.Printf("  ✗ %s: %v\n", filepath.Base(d), err)
+				failed++
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d app(s) failed", failed, len(dirs))
+		}
+		return nil
+	},
+}
+
+func init() {
+	appsBackupCmd.Flags().Bool("all-user", false, "back up every user-installed app")
+	appsBackupCmd.Flags().Bool("force", false, "overwrite an existing backup of the same version")
+	appsRestoreCmd.Flags().Duration("timeout", 3*time.Minute, "how long to wait for each install result")
+
+	appsCmd.AddCommand(appsBackupCmd)
+	appsCmd.AddCommand(appsRestoreCmd)
+}
+
+func backupApp(c *client.Client, dev *client.Device, pkg, dir string, force bool) error {
+	data, err := c.RunRaw(newCmd("apps", []string{"info", pkg}, nil))
+	if err != nil {
+		return err
+	}
+	rec := appRecordFromData(data)
+
+	dest := filepath.Join(dir, fmt.Sprintf("%s-%d", rec.Package, rec.VersionCode))
+	if _, err := os.Stat(filepath.Join(dest, "metadata.json")); err == nil && !force {
+		dim.Printf("  - %s %s already backed up\n", rec.Package, rec.VersionName)
+		return nil
+	}
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return err
+	}
+
+	remote := []string{str(data["apk_path"])}
+	if splits, ok := data["split_apks"].([]interface{}); ok {
+		for _, s := range splits {
+			remote = append(remote, str(s))
+		}
+	}
+
+	meta := appBackupMeta{appRecord: rec, Device: dev.Name, BackedUp: time.Now()}
+	var total int64
+	for i, r := range remote {
+		b, _, err := downloadBytes(c, r)
+		if err != nil {
+			return fmt.Errorf("pulling %s: %w", r, err)
+		}
+		name := path.Base(r)
+		if i == 0 {
+			name = "base.apk"
+		}
+		if err := os.WriteFile(filepath.Join(dest, name), b, 0644); err != nil {
+			return err
+		}
+		meta.APKs = append(meta.APKs, name)
+		total += int64(len(b))
+	}
+
+	out, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dest, "metadata.json"), append(out, '\n'), 0644); err != nil {
+		return err
+	}
+	green.Printf("  ✓ %s %s (%d APK(s), %s) → %s\n", rec.Package, rec.VersionName, len(meta.APKs), formatSize(total), dest)
+	return nil
+}
+
+// backupDirs returns dir itself if it is one app's backup, otherwise every
+// app backup directly inside it.
+func backupDirs(dir string) ([]string, error) {
+	if _, err := os.Stat(filepath.Join(dir, "metadata.json")); err == nil {
+		return []string{dir}, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var dirs []string
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		d := filepath.Join(dir, e.Name())
+		if _, err := os.Stat(filepath.Join(d, "metadata.json")); err == nil {
+			dirs = append(dirs, d)
+		}
+	}
+	if len(dirs) == 0 {
+		return nil, fmt.Errorf("no app backups (metadata.json) in %s", dir)
+	}
+	sort.Strings(dirs)
+	return dirs, nil
+}
+
+func restoreApp(c *client.Client, dir string, timeout time.Duration) error {
+	raw, err := os.ReadFile(filepath.Join(dir, "metadata.json"))
+	if err != nil {
+		return err
+	}
+	var meta appBackupMeta
+	if err := json.Unmarshal(raw, &meta); err != nil {
+		return fmt.Errorf("parsing metadata.json: %w", err)
+	}
+	if len(meta.APKs) == 0 {
+		return fmt.Errorf("metadata.json lists no APKs")
+	}
+
+	var parts []apkPart
+	for _, name := range meta.APKs {
+		if strings.ContainsAny(name, `/\`) {
+			return fmt.Errorf("invalid APK name %q in metadata.json", name)
+		}
+		b, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return err
+		}
+		parts = append(parts, apkPart{Name: name, Data: b})
+	}
+	return installParts(c, fmt.Sprintf("%s %s", meta.Package, meta.VersionName), parts, timeout)
+}
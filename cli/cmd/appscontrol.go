@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// appControl describes one of the app state commands (stop, clear-data, ...).
+type appControl struct {
+	action  string
+	short   string
+	done    string
+	confirm string // non-empty: ask before running
+}
+
+var appControls = []appControl{
+	{action: "stop", short: "Force-stop an app", done: "Stopped"},
+	{action: "clear-cache", short: "Clear an app's cache", done: "Cleared cache of"},
+	{action: "clear-data", short: "Clear all of an app's data (like a fresh install)", done: "Cleared data of",
+		confirm: "Delete all data of %s (accounts, settings, databases)?"},
+	{action: "disable", short: "Disable (hide) an app without uninstalling it", done: "Disabled",
+		confirm: "Disable %s? It disappears from the launcher until enabled again."},
+	{action: "enable", short: "Re-enable a disabled app", done: "Enabled"},
+}
+
+func init() {
+	for _, ctl := range appControls {
+		ctl := ctl
+		cmd := &cobra.Command{
+			Use:   ctl.action + " <name-or-package>",
+			Short: ctl.short,
+			Long: ctl.short + `.
+
+Clearing data and disabling/enabling happen directly when PhoneSSH is device
+owner (adb shell dpm set-device-owner com.phonessh.app/.PshDeviceAdminReceiver,
+test phones only); disabled apps are hidden from the launcher and app list
+until enabled again. Android has no public API for force-stop or clearing
+only the cache, so for those — and on phones where PhoneSSH is not device
+owner — the app's settings page opens on the phone instead.
+
+Exit status: 0 when the change was made, 3 when the settings page was opened
+instead, 1 on other errors.`,
+			Args: cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return runAppControl(cmd, ctl, args[0])
+			},
+		}
+		if ctl.confirm != "" {
+			cmd.Flags().BoolP("yes", "y", false, "skip the confirmation prompt")
+		}
+		appsCmd.AddCommand(cmd)
+	}
+}
+
+func runAppControl(cmd *cobra.Command, ctl appControl, query string) error {
+	c, _ := mustConnect()
+	defer c.Close()
+
+	var pkg, label string
+	if ctl.action == "enable" && strings.Contains(query, ".") {
+		// Disabled apps drop out of the app list; take a package name as is
+		pkg, label = query, query
+	} else {
+		var err error
+		if pkg, label, err = resolveApp(c, query); err != nil {
+			return err
+		}
+	}
+
+	if ctl.confirm != "" {
+		yes, _ := cmd.Flags().GetBool("yes")
+		if ok, err := confirmAction(yes, ctl.action, fmt.Sprintf(ctl.confirm, fmt.Sprintf("%s (%s)", label, pkg))); !ok {
+			return err
+		}
+	}
+
+	data, err := c.RunRaw(newCmd("apps", []string{ctl.action, pkg}, nil))
+	if err != nil {
+		return err
+	}
+	if applied, _ := data["applied"].(bool); applied {
+		green.Printf("%s %s\n", ctl.done, pkg)
+		return nil
+	}
+	notApplied(fmt.Sprintf("%s %s", ctl.action, pkg), data)
+	return nil
+}
+
+// exitNotApplied is the exit status of app commands when the phone could
+// not make the change itself and opened the settings page instead, so
+// scripts can tell it apart from success (0) and errors (1).
+const exitNotApplied = 3
+
+// notApplied reports a change the phone did not apply (what, e.g.
+// "disable com.example") with the phone's note and exits with exitNotApplied.
+func notApplied(what string, data map[string]interface{}) {
+	red.Fprintf(os.Stderr, "Error: could not %s directly\n", what)
+	if note := str(data["note"]); note != "" {
+		dim.Fprintln(os.Stderr, note)
+	}
+	os.Exit(exitNotApplied)
+}
+
+// confirmAction asks a yes/no question unless yes is set. It refuses when
+// prompting is not possible (--no-input or no terminal), and returns false
+// with a nil error when the user declines.
+func confirmAction(yes bool, action, question string) (bool, error) {
+	if yes {
+		return true, nil
+	}
+	if flagNoInput || !stdinIsTerminal() {
+		return false, fmt.Errorf("refusing to %s without confirmation — pass --yes", action)
+	}
+	fmt.Printf("%s [y/N] ", question)
+	var answer string
+	fmt.Scanln(&answer)
+	if strings.ToLower(answer) != "y" {
+		fmt.Println("Cancelled.")
+		return false, nil
+	}
+	return true, nil
+}
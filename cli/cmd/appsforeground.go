@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// foregroundApp is the app (and activity, when known) in the foreground.
+type foregroundApp struct {
+	Time     time.Time `json:"time"`
+	Package  string    `json:"package"`
+	Name     string    `json:"name,omitempty"`
+	Activity string    `json:"activity,omitempty"`
+}
+
+func foregroundFromData(data map[string]interface{}) foregroundApp {
+	ts, _ := data["time"].(float64)
+	return foregroundApp{
+		Time:     time.UnixMilli(int64(ts)),
+		Package:  str(data["package"]),
+		Name:     str(data["name"]),
+		Activity: str(data["activity"]),
+	}
+}
+
+// component formats the app like 'am' does: com.app/.ui.MainActivity.
+func (f foregroundApp) component() string {
+	if f.Activity == "" {
+		return f.Package
+	}
+	if strings.HasPrefix(f.Activity, f.Package+".") {
+		return f.Package + "/" + strings.TrimPrefix(f.Activity, f.Package)
+	}
+	return f.Package + "/" + f.Activity
+}
+
+var appsCurrentCmd = &cobra.Command{
+	Use:   "current",
+	Short: "Show the app and activity on screen",
+	Long: `Show which app (and activity, when known) is in the foreground. Needs the
+PhoneSSH accessibility service.
+
+Examples:
+  psh apps current
+  psh apps current --package     Just the package name, for scripts`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pkgOnly, _ := cmd.Flags().GetBool("package")
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		c, _ := mustConnect()
+		defer c.Close()
+
+		data, err := c.RunRaw(newCmd("apps", []string{"current"}, nil))
+		if err != nil {
+			return err
+		}
+		fg := foregroundFromData(data)
+		switch {
+		case pkgOnly:
+			fmt.Println(fg.Package)
+		case asJSON:
+			return json.NewEncoder(os.Stdout).Encode(fg)
+		default:
+			if fg.Name != "" {
+				bold.Printf("%s ", fg.Name)
+			}
+			fmt.Println(fg.Package)
+			if fg.Activity != "" {
+				dim.Printf("%s\n", fg.component())
+			}
+		}
+		return nil
+	},
+}
+
+var appsWatchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Stream foreground app and activity changes",
+	Long: `Print a line every time the foreground app or activity changes, until
+interrupted. Needs the PhoneSSH accessibility service.
+
+Examples:
+  psh apps watch
+  psh apps watch --json >> timeline.ndjson`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		interval, _ := cmd.Flags().GetDuration("interval")
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		c, _ := mustConnect()
+		defer c.Close()
+
+		// Without --after the phone only reports its latest sequence number,
+		// so older history is not replayed
+		data, err := c.RunRaw(newCmd("apps", []string{"foreground"}, nil))
+		if err != nil {
+			return err
+		}
+		seq, _ := data["seq"].(float64)
+		if !asJSON {
+			dim.Fprintln(os.Stderr, "Watching foreground changes (Ctrl+C to stop)")
+		}
+		if cur, err := c.RunRaw(newCmd("apps", []string{"current"}, nil)); err == nil {
+			printForeground(foregroundFromData(cur), asJSON)
+		}
+
+		for {
+			time.Sleep(interval)
+			data, err := c.RunRaw(newCmd("apps", []string{"foreground"}, map[string]string{"after": strconv.FormatInt(int64(seq), 10)}))
+			if err != nil {
+				return err
+			}
+			seq, _ = data["seq"].(float64)
+			changes, _ := data["changes"].([]interface{})
+			for _, ch := range changes {
+				if m, ok := ch.(map[string]interface{}); ok {
+					printForeground(foregroundFromData(m), asJSON)
+				}
+			}
+		}
+	},
+}
+
+func init() {
+	appsCurrentCmd.Flags().Bool("package", false, "print only the package name")
+	appsCurrentCmd.Flags().Bool("json", false, "output as JSON")
+	appsWatchCmd.Flags().Duration("interval", 500*time.Millisecond, "how often to poll the phone")
+	appsWatchCmd.Flags().Bool("json", false, "one JSON object per change")
+
+	appsCmd.AddCommand(appsCurrentCmd)
+	appsCmd.AddCommand(appsWatchCmd)
+}
+
+func printForeground(fg foregroundApp, asJSON bool) {
+	if asJSON {
+		json.NewEncoder(os.Stdout).Encode(fg)
+		return
+	}
+	name := fg.Name
+	if name == "" {
+		name = fg.Package
+	}
+	fmt.Printf("%s  %s  %s\n", dim.Sprint(fg.Time.Format("15:04:05")), bold.Sprint(name), cyan.Sprint(fg.component()))
+}
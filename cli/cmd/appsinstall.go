@@ -0,0 +1,322 @@
+package cmd
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/phonessh/psh/client"
+)
+
+// apkPart is one APK of an install set (a plain APK, or base + splits).
+type apkPart struct {
+	Name string
+	Data []byte
+}
+
+// deviceSpec is what picks the config splits of a bundle for a phone.
+type deviceSpec struct {
+	ABIs      []string // preferred first, bundletool style (arm64_v8a)
+	Density   int      // dpi
+	Languages []string
+}
+
+// phoneSpec asks the phone for its ABIs, screen density and languages.
+func phoneSpec(c *client.Client) (*deviceSpec, error) {
+	data, err := c.RunRaw(newCmd("status", nil, nil))
+	if err != nil {
+		return nil, err
+	}
+	dev, _ := data["device"].(map[string]interface{})
+	abis, _ := dev["abis"].([]interface{})
+	if len(abis) == 0 {
+		return nil, fmt.Errorf("the phone did not report its ABIs — update the PhoneSSH app")
+	}
+	spec := &deviceSpec{}
+	for _, a := range abis {
+		spec.ABIs = append(spec.ABIs, strings.ReplaceAll(str(a), "-", "_"))
+	}
+	density, _ := dev["density"].(float64)
+	spec.Density = int(density)
+	langs, _ := dev["languages"].([]interface{})
+	for _, l := range langs {
+		spec.Languages = append(spec.Languages, str(l))
+	}
+	return spec, nil
+}
+
+var splitABIs = map[string]bool{
+	"armeabi": true, "armeabi_v7a": true, "arm64_v8a": true,
+	"x86": true, "x86_64": true, "mips": true, "mips64": true,
+}
+
+// splitDensities are the density buckets of config splits, in dpi.
+var splitDensities = map[string]int{
+	"ldpi": 120, "mdpi": 160, "tvdpi": 213, "hdpi": 240,
+	"xhdpi": 320, "xxhdpi": 480, "xxxhdpi": 640,
+}
+
+// splitConfig returns the config an APK file name targets (arm64_v8a,
+// xxhdpi, en...) or "" for a base, master or feature APK. It understands
+// bundletool (splits/base-xxhdpi.apk), SAI (split_config.en.apk) and XAPK
+// (config.arm64_v8a.apk) names.
+func splitConfig(name string) string {
+	name = strings.TrimSuffix(strings.ToLower(path.Base(name)), ".apk")
+	for _, prefix := range []string{"split_config.", "config."} {
+		if strings.HasPrefix(name, prefix) {
+			return strings.TrimPrefix(name, prefix)
+		}
+	}
+	if i := strings.LastIndex(name, "-"); i >= 0 {
+		if cfg := name[i+1:]; splitABIs[cfg] || splitDensities[cfg] > 0 || isLanguageConfig(cfg) {
+			return cfg
+		}
+	}
+	return ""
+}
+
+func isLanguageConfig(cfg string) bool {
+	if len(cfg) < 2 || len(cfg) > 3 {
+		return false
+	}
+	for _, r := range cfg {
+		if r < 'a' || r > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+// selectSplits keeps the base, master and feature APKs, and of the config
+// splits the best ABI and density for spec and its languages, the way the
+// Play Store would. Skipped lists the config splits left out.
+func selectSplits(apks []*zip.File, spec *deviceSpec) (keep []*zip.File, picked, skipped []string) {
+	abis, densities := map[string]bool{}, map[string]bool{}
+	for _, f := range apks {
+		cfg := splitConfig(f.Name)
+		switch {
+		case splitABIs[cfg]:
+			abis[cfg] = true
+		case splitDensities[cfg] > 0:
+			densities[cfg] = true
+		}
+	}
+
+	abi := ""
+	for _, a := range spec.ABIs {
+		if abis[a] {
+			abi = a
+			break
+		}
+	}
+	// The smallest bucket at or above the screen's density, else the largest
+	density, best := "", 0
+	for d := range densities {
+		dpi := splitDensities[d]
+		better := density == "" ||
+			(dpi >= spec.Density && (best < spec.Density || dpi < best)) ||
+			(dpi < spec.Density && best < spec.Density && dpi > best)
+		if better {
+			density, best = d, dpi
+		}
+	}
+	langs, seen := map[string]bool{}, map[string]bool{}
+	for _, l := range spec.Languages {
+		langs[strings.ToLower(l)] = true
+	}
+
+	for _, f := range apks {
+		cfg := splitConfig(f.Name)
+		ok := false
+		switch {
+		case cfg == "":
+			ok = true
+		case splitABIs[cfg]:
+			ok = cfg == abi
+		case splitDensities[cfg] > 0:
+			ok = cfg == density
+		case isLanguageConfig(cfg):
+			ok = langs[cfg]
+		default:
+			ok = true
+		}
+		if !ok {
+			skipped = append(skipped, cfg)
+			continue
+		}
+		keep = append(keep, f)
+		if cfg != "" && !seen[cfg] {
+			seen[cfg] = true
+			picked = append(picked, cfg)
+		}
+	}
+	return keep, picked, skipped
+}
+
+// readInstallSet loads the APKs to install from a local .apk, or from an
+// .apks (bundletool / SAI) or .xapk bundle. From a bundle it takes
+// universal.apk when there is one, otherwise the base and feature APKs plus
+// the config splits matching spec; bundletool's standalones/ are never used.
+// Notes describe anything skipped.
+func readInstallSet(localPath string, spec *deviceSpec) (parts []apkPart, notes []string, err error) {
+	switch strings.ToLower(filepath.Ext(localPath)) {
+	case ".apk":
+		data, err := os.ReadFile(localPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		return []apkPart{{Name: "base.apk", Data: data}}, nil, nil
+	case ".apks", ".xapk", ".apkm", ".zip":
+	default:
+		return nil, nil, fmt.Errorf("%s: expected an .apk, .apks or .xapk file", localPath)
+	}
+
+	zr, err := zip.OpenReader(localPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("opening bundle %s: %w", localPath, err)
+	}
+	defer zr.Close()
+
+	var apks, splits, universal []*zip.File
+	obb := 0
+	for _, f := range zr.File {
+		name := strings.ToLower(f.Name)
+		switch {
+		case strings.HasSuffix(name, ".apk"):
+			switch {
+			case path.Base(name) == "universal.apk":
+				universal = append(universal, f)
+			case strings.HasPrefix(name, "splits/"):
+				splits = append(splits, f)
+			case strings.HasPrefix(name, "standalones/"):
+			default:
+				apks = append(apks, f)
+			}
+		case strings.HasSuffix(name, ".obb"):
+			obb++
+		}
+	}
+	switch {
+	case len(universal) == 1:
+		// bundletool --mode=universal: one self-contained APK
+		apks = universal
+	case len(splits) > 0:
+		// bundletool's default output: install from splits/ only
+		apks = splits
+	}
+	if len(apks) == 0 {
+		return nil, nil, fmt.Errorf("no installable APKs inside %s", localPath)
+	}
+	if obb > 0 {
+		notes = append(notes, fmt.Sprintf("%d OBB expansion file(s) in the bundle were not installed", obb))
+	}
+	if len(apks) > 1 && spec != nil {
+		var picked, skipped []string
+		apks, picked, skipped = selectSplits(apks, spec)
+		if len(skipped) > 0 {
+			notes = append(notes, fmt.Sprintf("installing the %s split(s); skipped %d for other devices",
+				strings.Join(picked, ", "), len(skipped)))
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, f := range apks {
+		rc, err := f.Open()
+		if err != nil {
+			return nil, nil, err
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading %s from bundle: %w", f.Name, err)
+		}
+		name := path.Base(f.Name)
+		if seen[name] {
+			name = strconv.Itoa(len(parts)) + "-" + name
+		}
+		seen[name] = true
+		parts = append(parts, apkPart{Name: name, Data: data})
+	}
+	return parts, notes, nil
+}
+
+// installLocal installs a local .apk or split bundle.
+func installLocal(c *client.Client, localPath string, timeout time.Duration) error {
+	var spec *deviceSpec
+	if !strings.EqualFold(filepath.Ext(localPath), ".apk") {
+		var err error
+		if spec, err = phoneSpec(c); err != nil {
+			return err
+		}
+	}
+	parts, notes, err := readInstallSet(localPath, spec)
+	if err != nil {
+		return err
+	}
+	for _, n := range notes {
+		dim.Fprintf(os.Stderr, "Note: %s\n", n)
+	}
+	return installParts(c, filepath.Base(localPath), parts, timeout)
+}
+
+// installParts uploads APKs to the phone's staging dir, installs them as one
+// session and waits for the outcome.
+func installParts(c *client.Client, label string, parts []apkPart, timeout time.Duration) error {
+	total := 0
+	for _, p := range parts {
+		total += len(p.Data)
+	}
+	fmt.Printf("Uploading %s (%d APK(s), %s) ...\n", label, len(parts), formatSize(int64(total)))
+
+	staging := fmt.Sprintf("%s/install-%d", phoneStagingDir, time.Now().UnixNano())
+	// The phone removes the staging dir once the session finishes; clean it
+	// up here when we give up before that
+	finished := false
+	defer func() {
+		if !finished {
+			c.RunRaw(newCmd("rm", []string{staging}, nil))
+		}
+	}()
+	for _, p := range parts {
+		if err := uploadBytes(c, p.Data, staging+"/"+p.Name, p.Name); err != nil {
+			return err
+		}
+	}
+
+	fmt.Println("Installing ...")
+	data, err := c.RunRaw(newCmd("apps", []string{"install-session"}, map[string]string{"dir": staging}))
+	if err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(timeout)
+	for done, _ := data["done"].(bool); !done; done, _ = data["done"].(bool) {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("no install result after %s — check the phone for a confirmation prompt", timeout)
+		}
+		time.Sleep(2 * time.Second)
+		session, _ := data["session"].(float64)
+		data, err = c.RunRaw(newCmd("apps", []string{"install-status", strconv.Itoa(int(session))}, nil))
+		if err != nil {
+			return err
+		}
+	}
+	finished = true
+
+	if ok, _ := data["success"].(bool); !ok {
+		msg := str(data["message"])
+		if msg == "" {
+			msg = str(data["status"])
+		}
+		return fmt.Errorf("install failed: %s", msg)
+	}
+	code, _ := data["version_code"].(float64)
+	green.Printf("Installed %v %v (%d)\n", data["package"], data["version_name"], int64(code))
+	return nil
+}
@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var permNameRe = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*(\.[A-Za-z0-9_]+)*$`)
+
+var appsPermsCmd = &cobra.Command{
+	Use:   "perms <name-or-package> [grant|revoke <permission>] | --who-has <permission>",
+	Short: "Show, grant or revoke an app's runtime permissions",
+	Long: `Show which runtime permissions an app requests and which are granted,
+change one, or list every app holding a permission.
+
+Permissions can be given short (CAMERA) or fully qualified
+(android.permission.CAMERA).
+
+Granting and revoking happen directly when PhoneSSH is device owner
+(adb shell dpm set-device-owner com.phonessh.app/.PshDeviceAdminReceiver,
+test phones only). Otherwise the app's settings page opens on the phone and
+the command exits with status 3.
+
+Examples:
+  psh apps perms whatsapp
+  psh apps perms whatsapp --all          Include install-time permissions
+  psh apps perms com.example.app revoke CAMERA
+  psh apps perms --who-has CAMERA
+  psh apps perms --who-has ACCESS_FINE_LOCATION --system`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if who, _ := cmd.Flags().GetString("who-has"); who != "" {
+			return cobra.NoArgs(cmd, args)
+		}
+		switch len(args) {
+		case 1:
+			return nil
+		case 3:
+			if args[1] == "grant" || args[1] == "revoke" {
+				return nil
+			}
+		}
+		return fmt.Errorf("usage: psh apps perms <app> [grant|revoke <permission>] | psh apps perms --who-has <permission>")
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if who, _ := cmd.Flags().GetString("who-has"); who != "" {
+			return permsWhoHas(cmd, who)
+		}
+		if len(args) == 3 {
+			return permsSet(args[0], args[1], args[2])
+		}
+		all, _ := cmd.Flags().GetBool("all")
+
+		c, _ := mustConnect()
+		defer c.Close()
+
+		pkg, _, err := resolveApp(c, args[0])
+		if err != nil {
+			return err
+		}
+		data, err := c.RunRaw(newCmd("apps", []string{"perms", pkg}, nil))
+		if err != nil {
+			return err
+		}
+		perms, _ := data["permissions"].([]interface{})
+
+		bold.Printf("%v ", data["name"])
+		dim.Printf("%v\n\n", data["package"])
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		shown, granted := 0, 0
+		for _, p := range perms {
+			perm, ok := p.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			runtime := perm["runtime"] == true
+			if !runtime && !all {
+				continue
+			}
+			status := dim.Sprint("denied")
+			if perm["granted"] == true {
+				status = green.Sprint("granted")
+				granted++
+			}
+			kind := ""
+			if !runtime {
+				kind = dim.Sprint("install-time")
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", status, shortPermName(str(perm["permission"])), dim.Sprint(str(perm["label"])), kind)
+			shown++
+		}
+		w.Flush()
+		if shown == 0 {
+			dim.Println("No runtime permissions requested")
+			return nil
+		}
+		fmt.Printf("\n%d of %d granted\n", granted, shown)
+		return nil
+	},
+}
+
+func init() {
+	appsPermsCmd.Flags().String("who-has", "", "list apps holding this permission (e.g. CAMERA)")
+	appsPermsCmd.Flags().Bool("system", false, "with --who-has, include system apps")
+	appsPermsCmd.Flags().Bool("all", false, "also show install-time (non-runtime) permissions")
+
+	appsCmd.AddCommand(appsPermsCmd)
+}
+
+func permsSet(app, action, perm string) error {
+	if !permNameRe.MatchString(perm) {
+		return fmt.Errorf("invalid permission name %q", perm)
+	}
+	c, _ := mustConnect()
+	defer c.Close()
+
+	pkg, _, err := resolveApp(c, app)
+	if err != nil {
+		return err
+	}
+	data, err := c.RunRaw(newCmd("apps", []string{"perms", pkg, action, perm}, nil))
+	if err != nil {
+		return err
+	}
+	if applied, _ := data["applied"].(bool); applied {
+		verb := "Granted"
+		if action == "revoke" {
+			verb = "Revoked"
+		}
+		green.Printf("%s %s for %v\n", verb, shortPermName(str(data["permission"])), data["package"])
+		return nil
+	}
+	notApplied(fmt.Sprintf("%s %s", action, shortPermName(str(data["permission"]))), data)
+	return nil
+}
+
+func permsWhoHas(cmd *cobra.Command, perm string) error {
+	if !permNameRe.MatchString(perm) {
+		return fmt.Errorf("invalid permission name %q", perm)
+	}
+	flags := map[string]string{"who-has": perm}
+	if system, _ := cmd.Flags().GetBool("system"); system {
+		flags["system"] = "true"
+	}
+
+	c, _ := mustConnect()
+	defer c.Close()
+
+	data, err := c.RunRaw(newCmd("apps", []string{"perms"}, flags))
+	if err != nil {
+		return err
+	}
+	apps, _ := data["apps"].([]interface{})
+	if len(apps) == 0 {
+		dim.Printf("No apps hold %s\n", shortPermName(str(data["permission"])))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "NAME\tPACKAGE\n")
+	for _, a := range apps {
+		app, ok := a.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name := str(app["name"])
+		if app["system"] == true {
+			name += dim.Sprint(" (system)")
+		}
+		fmt.Fprintf(w, "%s\t%s\n", name, dim.Sprint(str(app["package"])))
+	}
+	w.Flush()
+	fmt.Printf("\n%d app(s) hold %s\n", len(apps), shortPermName(str(data["permission"])))
+	return nil
+}
+
+// shortPermName drops the android.permission. prefix for display.
+func shortPermName(name string) string {
+	return strings.TrimPrefix(name, "android.permission.")
+}
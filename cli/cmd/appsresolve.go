@@ -0,0 +1,200 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/phonessh/psh/client"
+	"github.com/phonessh/psh/fuzzy"
+	"github.com/spf13/cobra"
+)
+
+// maxAppChoices caps the picker shown for an ambiguous app name.
+const maxAppChoices = 10
+
+type installedApp struct {
+	Name    string
+	Package string
+}
+
+// resolveApp turns an app name, alias or partial package name into a package
+// name. Only an alias, an exact package name or an exact (unique) label is
+// accepted as is; anything else ranks installed apps by label and package and
+// prompts for a choice, which is remembered as an alias. A mistyped or stale
+// name therefore never silently acts on a different app.
+func resolveApp(c *client.Client, query string) (pkg, label string, err error) {
+	q := strings.TrimSpace(query)
+	if q == "" {
+		return "", "", fmt.Errorf("empty app name")
+	}
+	cfg, cfgErr := client.LoadConfig()
+	alias := ""
+	if cfgErr == nil {
+		alias = cfg.AppAliases[strings.ToLower(q)]
+	}
+
+	data, err := c.RunRaw(newCmd("apps", []string{"list"}, map[string]string{"system": "true"}))
+	if err != nil {
+		return "", "", err
+	}
+	var apps []installedApp
+	raw, _ := data["apps"].([]interface{})
+	for _, a := range raw {
+		if app, ok := a.(map[string]interface{}); ok {
+			apps = append(apps, installedApp{Name: str(app["name"]), Package: str(app["package"])})
+		}
+	}
+
+	for _, want := range []string{alias, q} {
+		for _, a := range apps {
+			if want != "" && a.Package == want {
+				return a.Package, a.Name, nil
+			}
+		}
+	}
+
+	var exact []installedApp
+	for _, a := range apps {
+		if strings.EqualFold(a.Name, q) {
+			exact = append(exact, a)
+		}
+	}
+	if len(exact) == 1 {
+		return exact[0].Package, exact[0].Name, nil
+	}
+
+	ranked := rankApps(apps, q)
+	if len(ranked) == 0 {
+		return "", "", fmt.Errorf("no installed app matches %q — see 'psh apps list --filter %s'", q, q)
+	}
+
+	if len(ranked) > maxAppChoices {
+		ranked = ranked[:maxAppChoices]
+	}
+	options := make([]string, len(ranked))
+	for i, m := range ranked {
+		options[i] = fmt.Sprintf("%-24s %s", apps[m.Index].Name, dim.Sprint(apps[m.Index].Package))
+	}
+	choice, err := pickOne(fmt.Sprintf("%q is not an exact app name — which app?", q), options)
+	if err != nil {
+		return "", "", err
+	}
+	a := apps[ranked[choice].Index]
+	if cfgErr == nil {
+		if err := saveAppAlias(cfg, q, a.Package); err == nil {
+			dim.Fprintf(os.Stderr, "Remembered %q → %s (change with 'psh apps alias')\n", q, a.Package)
+		}
+	}
+	return a.Package, a.Name, nil
+}
+
+// appNameCommands are the daemon's apps subcommands whose first argument is
+// a package name.
+var appNameCommands = map[string]bool{
+	"launch": true, "kill": true, "info": true, "uninstall": true, "perms": true,
+	"stop": true, "clear-cache": true, "clear-data": true, "disable": true, "enable": true,
+}
+
+// resolveAppArgs resolves the app in a raw `apps <sub> <name> ...` command
+// before it goes to the daemon, as the apps subcommands do. It is used where
+// commands are passed through as text (psh ai, agent rules); other commands
+// are returned unchanged.
+func resolveAppArgs(c *client.Client, cmd string, args []string) ([]string, error) {
+	if cmd != "apps" || len(args) < 2 || !appNameCommands[args[0]] {
+		return args, nil
+	}
+	if args[0] == "enable" && strings.Contains(args[1], ".") {
+		// Disabled apps drop out of the app list, as in runAppControl
+		return args, nil
+	}
+	pkg, _, err := resolveApp(c, args[1])
+	if err != nil {
+		return nil, err
+	}
+	return append([]string{args[0], pkg}, args[2:]...), nil
+}
+
+// rankApps scores each app by the better of its label and package name.
+func rankApps(apps []installedApp, query string) []fuzzy.Match {
+	var out []fuzzy.Match
+	for i, a := range apps {
+		score := max(fuzzy.Score(query, a.Name), fuzzy.Score(query, a.Package))
+		if score > 0 {
+			out = append(out, fuzzy.Match{Index: i, Name: a.Name, Score: score})
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Score > out[j].Score })
+	return out
+}
+
+func saveAppAlias(cfg *client.Config, alias, pkg string) error {
+	if cfg.AppAliases == nil {
+		cfg.AppAliases = map[string]string{}
+	}
+	cfg.AppAliases[strings.ToLower(alias)] = pkg
+	return client.SaveConfig(cfg)
+}
+
+var appsAliasCmd = &cobra.Command{
+	Use:   "alias [<name> <package>]",
+	Short: "List or set short names for apps",
+	Long: `App names that are not an exact label or package prompt for a choice, and
+the choice is remembered as an alias. List, set or remove aliases here.
+
+Examples:
+  psh apps alias
+  psh apps alias yt com.google.android.youtube
+  psh apps alias --rm yt`,
+	Args: cobra.RangeArgs(0, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := client.LoadConfig()
+		if err != nil {
+			return err
+		}
+		rm, _ := cmd.Flags().GetString("rm")
+
+		switch {
+		case rm != "":
+			if _, ok := cfg.AppAliases[strings.ToLower(rm)]; !ok {
+				return fmt.Errorf("no alias %q", rm)
+			}
+			delete(cfg.AppAliases, strings.ToLower(rm))
+			if err := client.SaveConfig(cfg); err != nil {
+				return err
+			}
+			green.Printf("Removed alias %s\n", rm)
+		case len(args) == 2:
+			if err := saveAppAlias(cfg, args[0], args[1]); err != nil {
+				return err
+			}
+			green.Printf("%s → %s\n", strings.ToLower(args[0]), args[1])
+		case len(args) == 1:
+			return fmt.Errorf("usage: psh apps alias <name> <package>")
+		default:
+			if len(cfg.AppAliases) == 0 {
+				dim.Println("No app aliases")
+				return nil
+			}
+			names := make([]string, 0, len(cfg.AppAliases))
+			for n := range cfg.AppAliases {
+				names = append(names, n)
+			}
+			sort.Strings(names)
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			for _, n := range names {
+				fmt.Fprintf(w, "%s\t%s\n", n, dim.Sprint(cfg.AppAliases[n]))
+			}
+			w.Flush()
+		}
+		return nil
+	},
+}
+
+func init() {
+	appsAliasCmd.Flags().String("rm", "", "remove an alias")
+
+	appsCmd.AddCommand(appsAliasCmd)
+}
@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/phonessh/psh/client"
+	"github.com/spf13/cobra"
+)
+
+// appSnapshot is the inventory written by 'psh apps snapshot'.
+type appSnapshot struct {
+	Device string      `json:"device"`
+	Taken  time.Time   `json:"taken"`
+	System bool        `json:"system"`
+	Apps   []appRecord `json:"apps"`
+}
+
+var appsSnapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Print an inventory of installed apps as JSON",
+	Long: `Print every installed app with its version, enabled state and install /
+update times as JSON, for comparing later with 'psh apps diff'.
+
+Examples:
+  psh apps snapshot > inventory.json
+  psh -d pixel-7 apps snapshot --system > pixel-7.json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		system, _ := cmd.Flags().GetBool("system")
+
+		c, dev := mustConnect()
+		defer c.Close()
+
+		snap, err := takeAppSnapshot(c, dev, system)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(snap)
+	},
+}
+
+var appsDiffCmd = &cobra.Command{
+	Use:   "diff <old.json> [new.json]",
+	Short: "Compare app inventories: added, removed, upgraded, downgraded",
+	Long: `Compare two snapshots from 'psh apps snapshot'. With one snapshot, it is
+compared against the apps installed on the phone right now — pick the phone
+with --device to compare across devices.
+
+Examples:
+  psh apps diff monday.json friday.json
+  psh apps diff inventory.json                  Against the phone now
+  psh apps diff pixel-7.json --device pixel-8   Against another phone`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		old, err := readAppSnapshot(args[0])
+		if err != nil {
+			return err
+		}
+
+		var cur *appSnapshot
+		if len(args) == 2 {
+			if cur, err = readAppSnapshot(args[1]); err != nil {
+				return err
+			}
+		} else {
+			c, dev := mustConnect()
+			defer c.Close()
+			if cur, err = takeAppSnapshot(c, dev, old.System); err != nil {
+				return err
+			}
+		}
+
+		fmt.Printf("%s %s → %s %s\n\n",
+			bold.Sprint(old.Device), dim.Sprint(old.Taken.Format("2006-01-02 15:04")),
+			bold.Sprint(cur.Device), dim.Sprint(cur.Taken.Format("2006-01-02 15:04")))
+		printAppDiff(old, cur)
+		return nil
+	},
+}
+
+func init() {
+	appsSnapshotCmd.Flags().Bool("system", false, "include system apps")
+
+	appsCmd.AddCommand(appsSnapshotCmd)
+	appsCmd.AddCommand(appsDiffCmd)
+}
+
+func takeAppSnapshot(c *client.Client, dev *client.Device, system bool) (*appSnapshot, error) {
+	flags := map[string]string{"details": "true"}
+	if system {
+		flags["system"] = "true"
+	}
+	data, err := c.RunRaw(newCmd("apps", []string{"list"}, flags))
+	if err != nil {
+		return nil, err
+	}
+	apps, _ := data["apps"].([]interface{})
+
+	snap := &appSnapshot{Device: dev.Name, Taken: time.Now(), System: system}
+	for _, a := range apps {
+		if app, ok := a.(map[string]interface{}); ok {
+			snap.Apps = append(snap.Apps, appRecordFromData(app))
+		}
+	}
+	sort.Slice(snap.Apps, func(i, j int) bool { return snap.Apps[i].Package < snap.Apps[j].Package })
+	return snap, nil
+}
+
+func readAppSnapshot(path string) (*appSnapshot, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var snap appSnapshot
+	if err := json.Unmarshal(raw, &snap); err != nil {
+		return nil, fmt.Errorf("%s: not an app snapshot: %w", path, err)
+	}
+	if snap.Device == "" {
+		snap.Device = path
+	}
+	return &snap, nil
+}
+
+func printAppDiff(old, cur *appSnapshot) {
+	before := map[string]appRecord{}
+	for _, a := range old.Apps {
+		before[a.Package] = a
+	}
+	after := map[string]appRecord{}
+	for _, a := range cur.Apps {
+		after[a.Package] = a
+	}
+
+	var added, removed []appRecord
+	var upgraded, downgraded, toggled [][2]appRecord
+	for _, a := range cur.Apps {
+		b, ok := before[a.Package]
+		switch {
+		case !ok:
+			added = append(added, a)
+		case a.VersionCode > b.VersionCode:
+			upgraded = append(upgraded, [2]appRecord{b, a})
+		case a.VersionCode < b.VersionCode:
+			downgraded = append(downgraded, [2]appRecord{b, a})
+		case a.Enabled != b.Enabled:
+			toggled = append(toggled, [2]appRecord{b, a})
+		}
+	}
+	for _, b := range old.Apps {
+		// A snapshot without system apps says nothing about them
+		if _, ok := after[b.Package]; !ok && (cur.System || !b.System) {
+			removed = append(removed, b)
+		}
+	}
+
+	if len(added)+len(removed)+len(upgraded)+len(downgraded)+len(toggled) == 0 {
+		green.Println("No differences")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, a := range added {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", green.Sprint("+ added"), a.Name, dim.Sprint(a.Package), appVersion(a))
+	}
+	for _, a := range removed {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", red.Sprint("- removed"), a.Name, dim.Sprint(a.Package), appVersion(a))
+	}
+	for _, p := range upgraded {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s → %s\n", cyan.Sprint("↑ upgraded"), p[1].Name, dim.Sprint(p[1].Package), appVersion(p[0]), appVersion(p[1]))
+	}
+	for _, p := range downgraded {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s → %s\n", highlight.Sprint("↓ downgraded"), p[1].Name, dim.Sprint(p[1].Package), appVersion(p[0]), appVersion(p[1]))
+	}
+	for _, p := range toggled {
+		state := "disabled"
+		if p[1].Enabled {
+			state = "enabled"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", dim.Sprint("~ "+state), p[1].Name, dim.Sprint(p[1].Package), appVersion(p[1]))
+	}
+	w.Flush()
+
+	var summary []string
+	for _, s := range []struct {
+		n    int
+		what string
+	}{{len(added), "added"}, {len(removed), "removed"}, {len(upgraded), "upgraded"}, {len(downgraded), "downgraded"}, {len(toggled), "enabled/disabled"}} {
+		if s.n > 0 {
+			summary = append(summary, fmt.Sprintf("%d %s", s.n, s.what))
+		}
+	}
+	fmt.Printf("\n%s\n", strings.Join(summary, ", "))
+}
+
+func appVersion(a appRecord) string {
+	return fmt.Sprintf("%s (%d)", a.VersionName, a.VersionCode)
+}
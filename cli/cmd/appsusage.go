@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// appUsage is one app's row in 'psh apps usage'.
+type appUsage struct {
+	Package        string        `json:"package"`
+	Name           string        `json:"name"`
+	Foreground     time.Duration `json:"-"`
+	ForegroundSecs int64         `json:"foreground_seconds"`
+	Launches       int           `json:"launches"`
+	LastUsed       *time.Time    `json:"last_used"`
+}
+
+var appsUsageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "Foreground time, launches and last use per app",
+	Long: `Show how much each app was used: time in the foreground, how often it was
+brought to the foreground, and when it was last used.
+
+Needs Usage access for PhoneSSH (Settings > Apps > Special app access >
+Usage access, or the button in the PhoneSSH app).
+
+Examples:
+  psh apps usage                    Last 7 days, top 20
+  psh apps usage --since 30d --top 0
+  psh apps usage --unused           Installed apps not used at all
+  psh apps usage --format csv > usage.csv`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sinceStr, _ := cmd.Flags().GetString("since")
+		top, _ := cmd.Flags().GetInt("top")
+		unused, _ := cmd.Flags().GetBool("unused")
+		format, _ := cmd.Flags().GetString("format")
+		format = strings.ToLower(format)
+		switch format {
+		case "table", "json", "csv":
+		default:
+			return fmt.Errorf("unknown format %q — use table, json or csv", format)
+		}
+		since, err := parseSince(sinceStr)
+		if err != nil {
+			return err
+		}
+
+		c, _ := mustConnect()
+		defer c.Close()
+
+		data, err := c.RunRaw(newCmd("apps", []string{"usage"},
+			map[string]string{"since": strconv.FormatInt(since.UnixMilli(), 10)}))
+		if err != nil {
+			return err
+		}
+		var usage []appUsage
+		raw, _ := data["apps"].([]interface{})
+		for _, r := range raw {
+			u, ok := r.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			fg, _ := u["foreground_ms"].(float64)
+			launches, _ := u["launches"].(float64)
+			row := appUsage{
+				Package:        str(u["package"]),
+				Name:           str(u["name"]),
+				Foreground:     time.Duration(fg) * time.Millisecond,
+				ForegroundSecs: int64(fg / 1000),
+				Launches:       int(launches),
+			}
+			if last, _ := u["last_used"].(float64); last > 0 {
+				t := time.UnixMilli(int64(last))
+				row.LastUsed = &t
+			}
+			usage = append(usage, row)
+		}
+
+		if unused {
+			// Installed user apps missing from the stats were never in the foreground
+			used := map[string]bool{}
+			for _, u := range usage {
+				if u.Foreground > 0 {
+					used[u.Package] = true
+				}
+			}
+			list, err := c.RunRaw(newCmd("apps", []string{"list"}, nil))
+			if err != nil {
+				return err
+			}
+			apps, _ := list["apps"].([]interface{})
+			usage = usage[:0]
+			for _, a := range apps {
+				if app, ok := a.(map[string]interface{}); ok && !used[str(app["package"])] {
+					usage = append(usage, appUsage{Package: str(app["package"]), Name: str(app["name"])})
+				}
+			}
+		} else {
+			sort.SliceStable(usage, func(i, j int) bool { return usage[i].Foreground > usage[j].Foreground })
+			if top > 0 && len(usage) > top {
+				usage = usage[:top]
+			}
+		}
+
+		switch format {
+		case "json":
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(usage)
+		case "csv":
+			w := csv.NewWriter(os.Stdout)
+			w.Write([]string{"package", "name", "foreground_seconds", "launches", "last_used"})
+			for _, u := range usage {
+				last := ""
+				if u.LastUsed != nil {
+					last = u.LastUsed.Format(time.RFC3339)
+				}
+				w.Write([]string{u.Package, u.Name, strconv.FormatInt(u.ForegroundSecs, 10), strconv.Itoa(u.Launches), last})
+			}
+			w.Flush()
+			return w.Error()
+		}
+
+		if len(usage) == 0 {
+			if unused {
+				dim.Println("Every installed app was used in this period")
+			} else {
+				dim.Println("No app usage recorded in this period")
+			}
+			return nil
+		}
+		if unused {
+			fmt.Printf("Not used since %s:\n\n", since.Format("Jan 02 15:04"))
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			for _, u := range usage {
+				fmt.Fprintf(w, "%s\t%s\n", u.Name, dim.Sprint(u.Package))
+			}
+			w.Flush()
+			fmt.Printf("\n%d app(s)\n", len(usage))
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "NAME\tFOREGROUND\tLAUNCHES\tLAST USED\tPACKAGE\n")
+		for _, u := range usage {
+			name := u.Name
+			if name == "" {
+				name = dim.Sprint("(uninstalled)")
+			}
+			last := dim.Sprint("—")
+			if u.LastUsed != nil {
+				last = u.LastUsed.Format("Jan 02 15:04")
+			}
+			fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n", name, u.Foreground.Round(time.Minute), u.Launches, last, dim.Sprint(u.Package))
+		}
+		w.Flush()
+		return nil
+	},
+}
+
+func init() {
+	appsUsageCmd.Flags().String("since", "7d", "period to report (e.g. 24h, 7d, 2006-01-02)")
+	appsUsageCmd.Flags().Int("top", 20, "show only the most used apps (0 for all)")
+	appsUsageCmd.Flags().Bool("unused", false, "list installed user apps with no foreground time instead")
+	appsUsageCmd.Flags().String("format", "table", "output format: table, json, csv")
+
+	appsCmd.AddCommand(appsUsageCmd)
+}
@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var callsCmd = &cobra.Command{
+	Use:   "calls",
+	Short: "Call log, dialing and hang-up",
+	Long: `See recent calls and place or end calls from the terminal.
+
+Examples:
+  psh calls                     Recent calls
+  psh calls list --missed       Missed calls only
+  psh calls list --since 2d
+  psh calls dial Alice
+  psh calls hangup`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return callsListCmd.RunE(cmd, args)
+	},
+}
+
+var callsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List recent calls",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		flags := map[string]string{}
+		if missed, _ := cmd.Flags().GetBool("missed"); missed {
+			flags["missed"] = "true"
+		}
+		if since, _ := cmd.Flags().GetString("since"); since != "" {
+			t, err := parseSince(since)
+			if err != nil {
+				return err
+			}
+			flags["since"] = strconv.FormatInt(t.UnixMilli(), 10)
+		}
+		if limit, _ := cmd.Flags().GetInt("limit"); limit > 0 {
+			flags["limit"] = strconv.Itoa(limit)
+		}
+
+		c, dev := mustConnect()
+		defer c.Close()
+
+		data, err := c.RunRaw(newCmd("calls", []string{"list"}, flags))
+		if err != nil {
+			return err
+		}
+		calls, _ := data["calls"].([]interface{})
+		if len(calls) == 0 {
+			dim.Println("No calls")
+			return nil
+		}
+
+		names := loadContactNames(c, dev)
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "  \tWHEN\tWHO\tNUMBER\tDURATION\n")
+		for _, cl := range calls {
+			call, ok := cl.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			number := str(call["number"])
+			who := str(call["name"])
+			if who == "" {
+				who = names.label(number)
+			}
+			if number == "" {
+				who = "(hidden)"
+			}
+			ts, _ := call["time"].(float64)
+			secs, _ := call["duration"].(float64)
+
+			dur := dim.Sprint("—")
+			if secs > 0 {
+				dur = (time.Duration(secs) * time.Second).String()
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+				callIcon(str(call["type"]), call["new"] == true),
+				time.UnixMilli(int64(ts)).Format("Jan 02 15:04"),
+				who,
+				dim.Sprint(number),
+				dur,
+			)
+		}
+		w.Flush()
+		return nil
+	},
+}
+
+var callsDialCmd = &cobra.Command{
+	Use:   "dial <number|contact>",
+	Short: "Call a number or contact",
+	Long: `Call a number or contact. With the phone permission granted the call is
+placed directly; otherwise the dialer opens with the number filled in.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, dev := mustConnect()
+		defer c.Close()
+
+		number, label, err := resolveRecipient(c, dev, joinArgs(args))
+		if err != nil {
+			return err
+		}
+		data, err := c.RunRaw(newCmd("calls", []string{"dial", number}, nil))
+		if err != nil {
+			return err
+		}
+		if placed, _ := data["placed"].(bool); placed {
+			green.Printf("Calling %s\n", label)
+		} else {
+			fmt.Printf("Dialer opened for %s — tap call on the phone\n", label)
+		}
+		return nil
+	},
+}
+
+var callsHangupCmd = &cobra.Command{
+	Use:   "hangup",
+	Short: "End the current call (or reject a ringing one)",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, _ := mustConnect()
+		defer c.Close()
+
+		if _, err := c.RunRaw(newCmd("calls", []string{"hangup"}, nil)); err != nil {
+			return err
+		}
+		green.Println("Call ended")
+		return nil
+	},
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{callsCmd, callsListCmd} {
+		cmd.Flags().Bool("missed", false, "only missed and rejected calls")
+		cmd.Flags().String("since", "", "only calls newer than this (e.g. 12h, 3d, 2006-01-02)")
+		cmd.Flags().Int("limit", 30, "max calls to show")
+	}
+
+	callsCmd.AddCommand(callsListCmd)
+	callsCmd.AddCommand(callsDialCmd)
+	callsCmd.AddCommand(callsHangupCmd)
+}
+
+// callIcon marks the call direction; unseen missed calls stand out.
+func callIcon(kind string, unseen bool) string {
+	switch kind {
+	case "incoming":
+		return green.Sprint("↙")
+	case "outgoing":
+		return cyan.Sprint("↗")
+	case "missed":
+		if unseen {
+			return red.Sprint("●")
+		}
+		return red.Sprint("✗")
+	case "rejected", "blocked":
+		return dim.Sprint("⊘")
+	case "voicemail":
+		return cyan.Sprint("✉")
+	}
+	return " "
+}
@@ -0,0 +1,394 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/phonessh/psh/client"
+	"github.com/phonessh/psh/fuzzy"
+	"github.com/spf13/cobra"
+)
+
+// contactCacheTTL is how long the local contacts cache is trusted before it
+// is re-fetched from the phone.
+const contactCacheTTL = 24 * time.Hour
+
+type contact struct {
+	ID      int64           `json:"id"`
+	Name    string          `json:"name"`
+	Starred bool            `json:"starred"`
+	Numbers []contactNumber `json:"numbers"`
+}
+
+type contactNumber struct {
+	Number string `json:"number"`
+	Type   string `json:"type"`
+}
+
+type contactCache struct {
+	Device   string    `json:"device"`
+	Updated  time.Time `json:"updated"`
+	Contacts []contact `json:"contacts"`
+}
+
+var contactsCmd = &cobra.Command{
+	Use:   "contacts",
+	Short: "Look up contacts on the phone",
+	Long: `List, search and show phone contacts.
+
+Contacts are cached locally for a day so SMS and notification output can show
+names instead of raw numbers. Use --refresh to re-read them from the phone.
+
+Examples:
+  psh contacts list
+  psh contacts search ali
+  psh contacts show "Alice Smith"
+  psh contacts list --refresh`,
+}
+
+var contactsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List contacts with phone numbers",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, dev := mustConnect()
+		defer c.Close()
+
+		refresh, _ := cmd.Flags().GetBool("refresh")
+		contacts, err := loadContacts(c, dev, refresh)
+		if err != nil {
+			return err
+		}
+		printContacts(contacts)
+		fmt.Printf("\n%d contact(s)\n", len(contacts))
+		return nil
+	},
+}
+
+var contactsSearchCmd = &cobra.Command{
+	Use:   "search <name-or-number>",
+	Short: "Fuzzy-search contacts by name or number",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, dev := mustConnect()
+		defer c.Close()
+
+		refresh, _ := cmd.Flags().GetBool("refresh")
+		contacts, err := loadContacts(c, dev, refresh)
+		if err != nil {
+			return err
+		}
+		matches := searchContacts(contacts, strings.Join(args, " "))
+		if len(matches) == 0 {
+			dim.Println("No matching contacts")
+			return nil
+		}
+		printContacts(matches)
+		return nil
+	},
+}
+
+var contactsShowCmd = &cobra.Command{
+	Use:   "show <contact-id|name>",
+	Short: "Show a contact's numbers and emails",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, dev := mustConnect()
+		defer c.Close()
+
+		query := strings.Join(args, " ")
+		id, err := strconv.ParseInt(query, 10, 64)
+		if err != nil {
+			refresh, _ := cmd.Flags().GetBool("refresh")
+			contacts, err := loadContacts(c, dev, refresh)
+			if err != nil {
+				return err
+			}
+			matches := searchContacts(contacts, query)
+			if len(matches) == 0 {
+				return fmt.Errorf("no contact matches %q", query)
+			}
+			best := matches[0]
+			if len(matches) > 1 && !fuzzy.Confident(rankContacts(contacts, query)) {
+				names := make([]string, len(matches))
+				for i, m := range matches {
+					names[i] = m.Name
+				}
+				i, err := pickOne(fmt.Sprintf("%q matches several contacts:", query), names)
+				if err != nil {
+					return err
+				}
+				best = matches[i]
+			}
+			id = best.ID
+		}
+
+		data, err := c.RunRaw(newCmd("contacts", []string{"show", strconv.FormatInt(id, 10)}, nil))
+		if err != nil {
+			return err
+		}
+		bold.Printf("%v", data["name"])
+		if starred, _ := data["starred"].(bool); starred {
+			fmt.Print(" ★")
+		}
+		fmt.Println()
+		if nums, ok := data["numbers"].([]interface{}); ok {
+			for _, n := range nums {
+				if num, ok := n.(map[string]interface{}); ok {
+					fmt.Printf("  %-20s %s\n", str(num["number"]), dim.Sprint(str(num["type"])))
+				}
+			}
+		}
+		if emails, ok := data["emails"].([]interface{}); ok {
+			for _, e := range emails {
+				fmt.Printf("  %-20s %s\n", str(e), dim.Sprint("email"))
+			}
+		}
+		dim.Printf("  id %v\n", data["id"])
+		return nil
+	},
+}
+
+func init() {
+	contactsCmd.PersistentFlags().Bool("refresh", false, "re-read contacts from the phone instead of the local cache")
+
+	contactsCmd.AddCommand(contactsListCmd)
+	contactsCmd.AddCommand(contactsSearchCmd)
+	contactsCmd.AddCommand(contactsShowCmd)
+}
+
+func printContacts(contacts []contact) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "NAME\tNUMBER\tTYPE\n")
+	for _, ct := range contacts {
+		for i, n := range ct.Numbers {
+			name := ct.Name
+			if i > 0 {
+				name = ""
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", name, n.Number, dim.Sprint(n.Type))
+		}
+	}
+	w.Flush()
+}
+
+// ── Cache ────────────────────────────────────────────────────────────────────
+
+func contactsCachePath() (string, error) {
+	dir, err := client.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "contacts.json"), nil
+}
+
+// loadContacts returns the cached contacts for dev, re-fetching them from
+// the phone when the cache is missing, stale, for another device, or refresh
+// is set.
+// contactsFailed remembers a failed fetch (typically a missing contacts
+// permission) per device for the rest of the process, so commands that look
+// names up repeatedly don't ask the phone again each time.
+var contactsFailed = map[string]error{}
+
+func loadContacts(c *client.Client, dev *client.Device, refresh bool) ([]contact, error) {
+	path, err := contactsCachePath()
+	if err != nil {
+		return nil, err
+	}
+
+	if !refresh {
+		if data, err := os.ReadFile(path); err == nil {
+			var cache contactCache
+			if json.Unmarshal(data, &cache) == nil && cache.Device == dev.Name &&
+				time.Since(cache.Updated) < contactCacheTTL {
+				return cache.Contacts, nil
+			}
+		}
+		if err, ok := contactsFailed[dev.Name]; ok {
+			return nil, err
+		}
+	}
+
+	data, err := c.RunRaw(newCmd("contacts", []string{"list"}, nil))
+	if err != nil {
+		contactsFailed[dev.Name] = err
+		return nil, err
+	}
+	cache := contactCache{Device: dev.Name, Updated: time.Now(), Contacts: contactsFromData(data)}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err == nil {
+		if out, err := json.Marshal(cache); err == nil {
+			os.WriteFile(path, out, 0600)
+		}
+	}
+	return cache.Contacts, nil
+}
+
+func contactsFromData(data map[string]interface{}) []contact {
+	// Round-trip through JSON rather than walking interface{} maps by hand
+	raw, err := json.Marshal(data["contacts"])
+	if err != nil {
+		return nil
+	}
+	var out []contact
+	json.Unmarshal(raw, &out)
+	return out
+}
+
+// contactNames maps phone numbers to contact names for display. It is
+// best-effort: without contacts permission it is simply empty.
+type contactNames map[string]string
+
+func loadContactNames(c *client.Client, dev *client.Device) contactNames {
+	names := contactNames{}
+	contacts, err := loadContacts(c, dev, false)
+	if err != nil {
+		return names
+	}
+	for _, ct := range contacts {
+		for _, n := range ct.Numbers {
+			if key := numberKey(n.Number); key != "" {
+				names[key] = ct.Name
+			}
+		}
+	}
+	return names
+}
+
+// label returns "Name" for a known number, or the number itself.
+func (cn contactNames) label(number string) string {
+	if name, ok := cn[numberKey(number)]; ok {
+		return name
+	}
+	return number
+}
+
+// numberKey reduces a phone number to its last 9 digits so that national and
+// international spellings of the same number compare equal.
+func numberKey(number string) string {
+	var digits []rune
+	for _, r := range number {
+		if r >= '0' && r <= '9' {
+			digits = append(digits, r)
+		}
+	}
+	if len(digits) < 5 {
+		return ""
+	}
+	if len(digits) > 9 {
+		digits = digits[len(digits)-9:]
+	}
+	return string(digits)
+}
+
+// looksLikeNumber reports whether s is a phone number or short code rather
+// than a contact name.
+func looksLikeNumber(s string) bool {
+	digits := 0
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			digits++
+		case strings.ContainsRune("+-() .", r):
+		default:
+			return false
+		}
+	}
+	return digits >= 3
+}
+
+// ── Search / resolution ──────────────────────────────────────────────────────
+
+func rankContacts(contacts []contact, query string) []fuzzy.Match {
+	names := make([]string, len(contacts))
+	for i, ct := range contacts {
+		names[i] = ct.Name
+	}
+	return fuzzy.Rank(query, names)
+}
+
+// searchContacts matches by number digits when the query looks like a number,
+// otherwise fuzzy-matches names.
+func searchContacts(contacts []contact, query string) []contact {
+	var out []contact
+	if looksLikeNumber(query) {
+		key := numberKey(query)
+		digits := strings.Map(func(r rune) rune {
+			if r >= '0' && r <= '9' {
+				return r
+			}
+			return -1
+		}, query)
+		for _, ct := range contacts {
+			for _, n := range ct.Numbers {
+				if (key != "" && numberKey(n.Number) == key) || strings.Contains(n.Number, digits) {
+					out = append(out, ct)
+					break
+				}
+			}
+		}
+		return out
+	}
+	for _, m := range rankContacts(contacts, query) {
+		out = append(out, contacts[m.Index])
+	}
+	return out
+}
+
+// resolveRecipient turns a number or contact name into a phone number.
+// Ambiguous or weak matches (several contacts, a name that only loosely
+// matches, or one contact with several numbers) prompt for a choice.
+func resolveRecipient(c *client.Client, dev *client.Device, target string) (number, label string, err error) {
+	if looksLikeNumber(target) {
+		return target, target, nil
+	}
+
+	contacts, err := loadContacts(c, dev, false)
+	if err != nil {
+		return "", "", fmt.Errorf("%q is not a phone number and contacts are unavailable: %w", target, err)
+	}
+	ranked := rankContacts(contacts, target)
+	if len(ranked) == 0 {
+		return "", "", fmt.Errorf("no contact matches %q — run 'psh contacts search %s' or use a number", target, target)
+	}
+
+	type option struct {
+		ct  contact
+		num contactNumber
+	}
+	var opts []option
+	confident := fuzzy.Confident(ranked)
+	candidates := ranked
+	if confident {
+		candidates = ranked[:1]
+	}
+	for _, m := range candidates {
+		for _, n := range contacts[m.Index].Numbers {
+			opts = append(opts, option{contacts[m.Index], n})
+		}
+	}
+
+	if len(opts) == 0 {
+		return "", "", fmt.Errorf("no phone number for contacts matching %q", target)
+	}
+
+	choice := 0
+	if len(opts) > 1 || !confident {
+		labels := make([]string, len(opts))
+		for i, o := range opts {
+			labels[i] = fmt.Sprintf("%-24s %s %s", o.ct.Name, o.num.Number, dim.Sprint(o.num.Type))
+		}
+		choice, err = pickOne(fmt.Sprintf("Which number did you mean by %q?", target), labels)
+		if err != nil {
+			return "", "", err
+		}
+	}
+	o := opts[choice]
+	return o.num.Number, fmt.Sprintf("%s (%s)", o.ct.Name, o.num.Number), nil
+}
@@ -5,13 +5,26 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"text/tabwriter"
 	"time"
 
+	"github.com/phonessh/psh/client"
 	"github.com/spf13/cobra"
 )
 
+// pushChunkSize is how much raw data goes in one push message; larger
+// files are sent as several appends.
+const pushChunkSize = 512 << 10
+
+// pullChunkSize is how much of a file one pull message fetches.
+const pullChunkSize = 1 << 20
+
+// phoneStagingDir is where psh uploads files that the daemon consumes
+// (APKs to install, files to share).
+const phoneStagingDir = "/sdcard/Download/.psh"
+
 var lsCmd = &cobra.Command{
 	Use:   "ls [remote-path]",
 	Short: "List files on the phone",
@@ -122,21 +135,11 @@ var pullCmd = &cobra.Command{
 		}
 
 		fmt.Printf("Downloading %s ...\n", remotePath)
-		data, err := c.RunRaw(newCmd("pull", []string{remotePath}, nil))
+		fileBytes, filename, err := downloadBytes(c, remotePath)
 		if err != nil {
 			return err
 		}
 
-		content, ok := data["content"].(string)
-		if !ok {
-			return fmt.Errorf("no content in response")
-		}
-		fileBytes, err := base64.StdEncoding.DecodeString(content)
-		if err != nil {
-			return fmt.Errorf("decoding file: %w", err)
-		}
-
-		filename := data["filename"].(string)
 		localPath := filepath.Join(localDir, filename)
 
 		// If localDir is a file path (has extension), use it directly
@@ -173,15 +176,10 @@ var pushCmd = &cobra.Command{
 		defer c.Close()
 
 		fmt.Printf("Uploading %s → %s ...\n", localPath, remotePath)
-		payload := base64.StdEncoding.EncodeToString(fileBytes)
-		msg := newCmd("push", []string{remotePath}, nil)
-		msg.Payload = payload
-
-		data, err := c.RunRaw(msg)
-		if err != nil {
+		if err := uploadBytes(c, fileBytes, remotePath, filepath.Base(localPath)); err != nil {
 			return err
 		}
-		green.Printf("Uploaded %s bytes to %s\n", formatSize(int64(data["written"].(float64))), remotePath)
+		green.Printf("Uploaded %s to %s\n", formatSize(int64(len(fileBytes))), remotePath)
 		return nil
 	},
 }
@@ -230,3 +228,79 @@ func formatSize(bytes int64) string {
 		return fmt.Sprintf("%.2f GB", float64(bytes)/1024/1024/1024)
 	}
 }
+
+// uploadBytes writes data to remotePath in chunks, showing progress on
+// stderr under label when it takes more than one chunk.
+func uploadBytes(c *client.Client, data []byte, remotePath, label string) error {
+	for offset := 0; ; offset += pushChunkSize {
+		end := offset + pushChunkSize
+		if end > len(data) {
+			end = len(data)
+		}
+		msg := newCmd("push", []string{remotePath}, map[string]string{"offset": strconv.Itoa(offset)})
+		msg.Payload = base64.StdEncoding.EncodeToString(data[offset:end])
+		resp, err := c.RunRaw(msg)
+		if err == nil {
+			// An older PhoneSSH app ignores --offset and overwrites the file
+			// with each chunk; its reply has no size
+			if size, ok := resp["size"].(float64); !ok || int(size) != end {
+				err = fmt.Errorf("file on the phone is %v bytes after writing %d — update the PhoneSSH app", resp["size"], end)
+			}
+		}
+		if err != nil {
+			if len(data) > pushChunkSize {
+				fmt.Fprintln(os.Stderr)
+			}
+			return fmt.Errorf("uploading %s: %w", label, err)
+		}
+		if len(data) > pushChunkSize {
+			fmt.Fprintf(os.Stderr, "\r  %s  %3d%%  %s", label, end*100/len(data), formatSize(int64(end)))
+		}
+		if end == len(data) {
+			break
+		}
+	}
+	if len(data) > pushChunkSize {
+		fmt.Fprintln(os.Stderr)
+	}
+	return nil
+}
+
+// downloadBytes fetches a phone file in chunks, showing progress on stderr
+// for files larger than one chunk. It returns the data and the file's name.
+func downloadBytes(c *client.Client, remotePath string) ([]byte, string, error) {
+	var out []byte
+	filename := filepath.Base(remotePath)
+	for {
+		data, err := c.RunRaw(newCmd("pull", []string{remotePath}, map[string]string{
+			"offset": strconv.Itoa(len(out)),
+			"length": strconv.Itoa(pullChunkSize),
+		}))
+		if err != nil {
+			return nil, "", err
+		}
+		content, ok := data["content"].(string)
+		if !ok {
+			return nil, "", fmt.Errorf("no content in response")
+		}
+		chunk, err := base64.StdEncoding.DecodeString(content)
+		if err != nil {
+			return nil, "", fmt.Errorf("decoding file: %w", err)
+		}
+		if name := str(data["filename"]); name != "" {
+			filename = name
+		}
+		out = append(out, chunk...)
+
+		size, _ := data["size"].(float64)
+		if size > pullChunkSize {
+			fmt.Fprintf(os.Stderr, "\r  %s  %3d%%  %s", filename, int64(len(out))*100/int64(size), formatSize(int64(len(out))))
+		}
+		if len(chunk) == 0 || float64(len(out)) >= size {
+			if size > pullChunkSize {
+				fmt.Fprintln(os.Stderr)
+			}
+			return out, filename, nil
+		}
+	}
+}
@@ -0,0 +1,292 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/phonessh/psh/client"
+	"github.com/phonessh/psh/flow"
+	"github.com/spf13/cobra"
+)
+
+const (
+	flowPollInterval = 300 * time.Millisecond
+	flowRetryDelay   = time.Second
+)
+
+var flowCmd = &cobra.Command{
+	Use:   "flow",
+	Short: "Run declarative UI test flows on the phone",
+	Long: `Run end-to-end UI checks written as YAML, on a real phone and without
+Appium. Every step maps onto an existing psh command.
+
+A flow file:
+
+  name: Login
+  env:
+    USER: qa@example.com            # default for ${USER}
+  steps:
+    - launch: example                # app name or package, as for 'psh apps launch'
+    - waitFor: "id=username"         # wait until on screen (timeout: 10s)
+    - type: ${USER}
+      into: "id=username"            # tap the field first
+    - type: ${PASSWORD}
+      into: "id=password"
+    - click: "class=Button && text=Sign in"
+      retries: 2                     # try again up to twice on failure
+    - assertVisible: "Welcome"       # waits up to timeout: (5s)
+    - assertNotVisible: "Error"
+    - waitFor: "id=progress"
+      gone: true
+    - swipe: "540 1500 540 500"
+      duration: 500ms
+    - key: back
+    - screenshot: home.png           # saved in --output
+    - runScript: ./check-backend.sh  # local shell command; non-zero exit fails
+
+click, assertVisible, assertNotVisible, waitFor and into take the selectors
+of 'psh click' (see 'psh ui find --help'). ${VAR} is filled from --env,
+the flow's env: section, then the environment. runScript runs in the flow
+file's directory with PSH_DEVICE, PSH_FLOW and PSH_OUTPUT set and the --env
+and env: variables exported; the shell expands ${VAR} there itself.`,
+}
+
+var flowRunCmd = &cobra.Command{
+	Use:   "run <flow.yaml>...",
+	Short: "Run flow files and report the results",
+	Long: `Run one or more flow files in order. A failing step (after its retries)
+fails the flow: a screenshot is saved to --output and the remaining steps
+are skipped. psh exits non-zero if any flow failed.
+
+Examples:
+  psh flow run login.yaml
+  psh flow run flows/*.yaml --report junit.xml --output artifacts
+  psh flow run login.yaml --env PASSWORD="$QA_PASSWORD" --retries 1`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		report, _ := cmd.Flags().GetString("report")
+		outDir, _ := cmd.Flags().GetString("output")
+		retries, _ := cmd.Flags().GetInt("retries")
+		envFlags, _ := cmd.Flags().GetStringArray("env")
+
+		vars := map[string]string{}
+		for _, e := range envFlags {
+			k, v, ok := strings.Cut(e, "=")
+			if !ok || k == "" {
+				return fmt.Errorf("--env takes KEY=VALUE, got %q", e)
+			}
+			vars[k] = v
+		}
+
+		// Validate every file before touching the phone
+		var flows []*flow.Flow
+		for _, path := range args {
+			fl, err := flow.Load(path, vars)
+			if err != nil {
+				return err
+			}
+			flows = append(flows, fl)
+		}
+
+		c, dev := mustConnect()
+		defer c.Close()
+
+		var results []*flow.Result
+		failed := 0
+		for _, fl := range flows {
+			r := runFlow(c, dev, fl, outDir, retries)
+			if r.Failed() {
+				failed++
+			}
+			results = append(results, r)
+		}
+
+		if report != "" {
+			if err := flow.WriteJUnit(report, results); err != nil {
+				return fmt.Errorf("writing report: %w", err)
+			}
+			dim.Printf("JUnit report: %s\n", report)
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d flow(s) failed", failed, len(flows))
+		}
+		green.Printf("%d flow(s) passed\n", len(flows))
+		return nil
+	},
+}
+
+func init() {
+	flowRunCmd.Flags().String("report", "", "write a JUnit XML report to this file")
+	flowRunCmd.Flags().StringP("output", "o", "psh-flow-output", "directory for screenshots")
+	flowRunCmd.Flags().Int("retries", 0, "retry every step at least this many times")
+	flowRunCmd.Flags().StringArrayP("env", "e", nil, "set a flow variable (KEY=VALUE, repeatable)")
+	flowCmd.AddCommand(flowRunCmd)
+}
+
+func runFlow(c *client.Client, dev *client.Device, fl *flow.Flow, outDir string, retries int) *flow.Result {
+	bold.Printf("▶ %s", fl.Name)
+	dim.Printf(" (%s)\n", fl.File)
+
+	res := &flow.Result{Flow: fl, Device: dev.Name, Started: time.Now()}
+	base := strings.TrimSuffix(filepath.Base(fl.File), filepath.Ext(fl.File))
+	failed := false
+	for i, st := range fl.Steps {
+		sr := flow.StepResult{Step: st}
+		if failed {
+			sr.Status = flow.Skipped
+			dim.Printf("  - %s (skipped)\n", st.Describe())
+			res.Steps = append(res.Steps, sr)
+			continue
+		}
+
+		start := time.Now()
+		for sr.Attempts = 1; ; sr.Attempts++ {
+			sr.Output, sr.Err = runFlowStep(c, dev, fl, st, outDir)
+			if sr.Err == nil || sr.Attempts > max(st.Retries, retries) {
+				break
+			}
+			dim.Printf("    attempt %d failed: %v — retrying\n", sr.Attempts, sr.Err)
+			time.Sleep(flowRetryDelay)
+		}
+		sr.Duration = time.Since(start)
+
+		if sr.Err == nil {
+			sr.Status = flow.Passed
+			green.Printf("  ✓ %s", st.Describe())
+			dim.Printf(" (%s)\n", sr.Duration.Round(100*time.Millisecond))
+		} else {
+			sr.Status = flow.Failed
+			failed = true
+			red.Printf("  ✗ %s: %v\n", st.Describe(), sr.Err)
+			name := fmt.Sprintf("%s-step%02d-failure.png", base, i+1)
+			if path, err := saveFlowScreenshot(c, outDir, name); err != nil {
+				dim.Printf("    could not save a screenshot: %v\n", err)
+			} else {
+				sr.Screenshot = path
+				dim.Printf("    screenshot: %s\n", path)
+			}
+		}
+		res.Steps = append(res.Steps, sr)
+	}
+	return res
+}
+
+// runFlowStep runs one step once. The returned string is script output.
+func runFlowStep(c *client.Client, dev *client.Device, fl *flow.Flow, st *flow.Step, outDir string) (string, error) {
+	switch st.Action {
+	case "launch":
+		pkg, _, err := resolveApp(c, st.Target)
+		if err != nil {
+			return "", err
+		}
+		_, err = c.RunRaw(newCmd("apps", []string{"launch", pkg}, nil))
+		return "", err
+
+	case "click":
+		n, err := waitForSelector(c, st.Selector, false, st.Timeout, flowPollInterval)
+		if errors.Is(err, errWaitTimeout) {
+			return "", fmt.Errorf("nothing to click: %s is not on screen after %s", st.Target, st.Timeout)
+		}
+		if err != nil {
+			return "", err
+		}
+		_, _, err = tapNode(c, n)
+		return "", err
+
+	case "type":
+		if st.Selector != nil {
+			n, err := waitForSelector(c, st.Selector, false, st.Timeout, flowPollInterval)
+			if errors.Is(err, errWaitTimeout) {
+				return "", fmt.Errorf("field %s is not on screen after %s", st.Into, st.Timeout)
+			}
+			if err != nil {
+				return "", err
+			}
+			if _, _, err := tapNode(c, n); err != nil {
+				return "", err
+			}
+			time.Sleep(300 * time.Millisecond)
+		}
+		_, err := c.RunRaw(newCmd("type", []string{st.Target}, nil))
+		return "", err
+
+	case "key":
+		_, err := c.RunRaw(newCmd("key", []string{st.Target}, nil))
+		return "", err
+
+	case "swipe":
+		args := make([]string, len(st.Swipe))
+		for i, v := range st.Swipe {
+			args[i] = strconv.Itoa(v)
+		}
+		flags := map[string]string{"duration": strconv.FormatInt(st.Duration.Milliseconds(), 10)}
+		_, err := c.RunRaw(newCmd("swipe", args, flags))
+		return "", err
+
+	case "assertVisible", "assertNotVisible", "waitFor":
+		gone := st.Action == "assertNotVisible" || st.Gone
+		_, err := waitForSelector(c, st.Selector, gone, st.Timeout, flowPollInterval)
+		if errors.Is(err, errWaitTimeout) && st.Action == "assertVisible" {
+			return "", fmt.Errorf("%s is not visible after %s", st.Target, st.Timeout)
+		}
+		if errors.Is(err, errWaitTimeout) && st.Action == "assertNotVisible" {
+			return "", fmt.Errorf("%s is still visible after %s", st.Target, st.Timeout)
+		}
+		return "", err
+
+	case "screenshot":
+		name := st.Target
+		if filepath.Ext(name) == "" {
+			name += ".png"
+		}
+		_, err := saveFlowScreenshot(c, outDir, name)
+		return "", err
+
+	case "runScript":
+		var sh *exec.Cmd
+		if runtime.GOOS == "windows" {
+			sh = exec.Command("cmd", "/C", st.Target)
+		} else {
+			sh = exec.Command("sh", "-c", st.Target)
+		}
+		absOut, _ := filepath.Abs(outDir)
+		sh.Dir = filepath.Dir(fl.File)
+		sh.Env = os.Environ()
+		for k, v := range fl.Env {
+			sh.Env = append(sh.Env, k+"="+v)
+		}
+		sh.Env = append(sh.Env,
+			"PSH_DEVICE="+dev.Name,
+			"PSH_FLOW="+fl.Name,
+			"PSH_OUTPUT="+absOut,
+		)
+		out, err := sh.CombinedOutput()
+		if s := strings.TrimSpace(string(out)); s != "" {
+			dim.Printf("    %s\n", strings.ReplaceAll(s, "\n", "\n    "))
+		}
+		if err != nil {
+			return string(out), fmt.Errorf("script failed: %w", err)
+		}
+		return string(out), nil
+	}
+	return "", fmt.Errorf("unknown action %q", st.Action)
+}
+
+func saveFlowScreenshot(c *client.Client, dir, name string) (string, error) {
+	img, err := takeScreenshot(c)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, name)
+	return path, os.WriteFile(path, img, 0644)
+}
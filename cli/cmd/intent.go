@@ -0,0 +1,369 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// intentSpec is the intent sent to the phone, mirroring 'am start' options.
+type intentSpec struct {
+	Action     string        `json:"action,omitempty"`
+	Data       string        `json:"data,omitempty"`
+	Type       string        `json:"type,omitempty"`
+	Categories []string      `json:"categories,omitempty"`
+	Component  string        `json:"component,omitempty"`
+	Package    string        `json:"package,omitempty"`
+	Flags      int           `json:"flags,omitempty"`
+	Extras     []intentExtra `json:"extras,omitempty"`
+}
+
+type intentExtra struct {
+	Key   string `json:"key"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// intentFlags are the Intent.FLAG_* values accepted by --flags, without the
+// FLAG_ / FLAG_ACTIVITY_ prefix.
+var intentFlags = map[string]int{
+	"GRANT_READ_URI_PERMISSION":  0x00000001,
+	"GRANT_WRITE_URI_PERMISSION": 0x00000002,
+	"INCLUDE_STOPPED_PACKAGES":   0x00000020,
+	"CLEAR_TASK":                 0x00008000,
+	"NO_ANIMATION":               0x00010000,
+	"REORDER_TO_FRONT":           0x00020000,
+	"EXCLUDE_FROM_RECENTS":       0x00800000,
+	"CLEAR_TOP":                  0x04000000,
+	"MULTIPLE_TASK":              0x08000000,
+	"NEW_TASK":                   0x10000000,
+	"RECEIVER_FOREGROUND":        0x10000000,
+	"SINGLE_TOP":                 0x20000000,
+	"NO_HISTORY":                 0x40000000,
+}
+
+// extraTypes maps accepted type names (and am-style short forms) to the
+// names the phone understands.
+var extraTypes = map[string]string{
+	"s": "string", "string": "string",
+	"i": "int", "int": "int",
+	"l": "long", "long": "long",
+	"f": "float", "float": "float",
+	"d": "double", "double": "double",
+	"b": "bool", "z": "bool", "bool": "bool", "boolean": "bool",
+	"u": "uri", "uri": "uri",
+	"sa": "string[]", "string[]": "string[]",
+	"ia": "int[]", "int[]": "int[]",
+	"la": "long[]", "long[]": "long[]",
+}
+
+var (
+	javaNameRe  = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_$]*)*$`)
+	shortNameRe = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+)
+
+var intentCmd = &cobra.Command{
+	Use:   "intent",
+	Short: "Start activities, send broadcasts or start services with any intent",
+	Long: `Fire an arbitrary Android intent, like 'am start' / 'am broadcast' /
+'am startservice'. The intent is checked here before it is sent.
+
+Options:
+  --action A          android.intent.action.VIEW, or just VIEW
+  --data URI          data URI (needs a scheme: https:, tel:, myapp:, ...)
+  --type MIME         MIME type
+  --category C        repeatable; LAUNCHER → android.intent.category.LAUNCHER
+  --component P/C     com.app/.ui.Settings or com.app/com.app.ui.Settings
+  --package P         limit resolution to one app
+  --extra K:T=V       repeatable; T is string (s), int (i), long (l), float (f),
+                      double (d), bool (b), uri (u), string[] (sa), int[] (ia)
+                      or long[] (la); arrays are comma separated. K=V is a string.
+  --flags F,F         NEW_TASK, CLEAR_TOP, CLEAR_TASK, SINGLE_TOP, NO_HISTORY,
+                      INCLUDE_STOPPED_PACKAGES, ... or a number like 0x10000000
+
+Examples:
+  psh intent start --action VIEW --data "myapp://orders/42"
+  psh intent start --component com.android.settings/.wifi.WifiSettings
+  psh intent start --component com.example/.DebugActivity --extra user:s=alice --extra retries:i=3
+  psh intent broadcast --action com.example.TEST_HOOK --package com.example --extra reset:b=true
+  psh intent service --component com.example/.SyncService --foreground`,
+}
+
+func init() {
+	for _, sub := range []struct{ name, short, verb string }{
+		{"start", "Start an activity", "Starting"},
+		{"broadcast", "Send a broadcast", "Broadcasting"},
+		{"service", "Start a service", "Starting service"},
+	} {
+		sub := sub
+		cmd := &cobra.Command{
+			Use:   sub.name,
+			Short: sub.short,
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return runIntent(cmd, sub.name, sub.verb)
+			},
+		}
+		cmd.Flags().String("action", "", "intent action")
+		cmd.Flags().String("data", "", "data URI")
+		cmd.Flags().String("type", "", "MIME type")
+		cmd.Flags().StringArray("category", nil, "category (repeatable)")
+		cmd.Flags().String("component", "", "explicit component, package/class")
+		cmd.Flags().String("package", "", "target package")
+		cmd.Flags().StringArray("extra", nil, "extra as key:type=value (repeatable)")
+		cmd.Flags().String("flags", "", "comma-separated intent flags or a number")
+		cmd.Flags().Bool("dry-run", false, "print the intent without sending it")
+		if sub.name == "service" {
+			cmd.Flags().Bool("foreground", false, "use startForegroundService")
+		}
+		intentCmd.AddCommand(cmd)
+	}
+}
+
+func runIntent(cmd *cobra.Command, kind, verb string) error {
+	spec, err := intentFromFlags(cmd)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s: %s\n", verb, spec)
+	if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+		return nil
+	}
+
+	payload, err := json.Marshal(spec)
+	if err != nil {
+		return err
+	}
+	flags := map[string]string{}
+	if fg, _ := cmd.Flags().GetBool("foreground"); fg {
+		flags["foreground"] = "true"
+	}
+	msg := newCmd("intent", []string{kind}, flags)
+	msg.Payload = base64.StdEncoding.EncodeToString(payload)
+
+	c, _ := mustConnect()
+	defer c.Close()
+
+	data, err := c.RunRaw(msg)
+	if err != nil {
+		return err
+	}
+	if svc := str(data["service"]); svc != "" {
+		green.Printf("Started %s\n", svc)
+	} else {
+		green.Println("Sent")
+	}
+	return nil
+}
+
+// intentFromFlags builds and validates an intent from the command's flags.
+func intentFromFlags(cmd *cobra.Command) (*intentSpec, error) {
+	spec := &intentSpec{}
+	spec.Action, _ = cmd.Flags().GetString("action")
+	spec.Data, _ = cmd.Flags().GetString("data")
+	spec.Type, _ = cmd.Flags().GetString("type")
+	spec.Component, _ = cmd.Flags().GetString("component")
+	spec.Package, _ = cmd.Flags().GetString("package")
+	categories, _ := cmd.Flags().GetStringArray("category")
+	extras, _ := cmd.Flags().GetStringArray("extra")
+	flags, _ := cmd.Flags().GetString("flags")
+
+	if spec.Action == "" && spec.Data == "" && spec.Component == "" && spec.Package == "" {
+		return nil, fmt.Errorf("give at least one of --action, --data, --component or --package")
+	}
+
+	if spec.Action != "" {
+		spec.Action = expandIntentName(spec.Action, "android.intent.action.")
+		if !javaNameRe.MatchString(spec.Action) {
+			return nil, fmt.Errorf("invalid --action %q", spec.Action)
+		}
+	}
+	for _, c := range categories {
+		c = expandIntentName(c, "android.intent.category.")
+		if !javaNameRe.MatchString(c) {
+			return nil, fmt.Errorf("invalid --category %q", c)
+		}
+		spec.Categories = append(spec.Categories, c)
+	}
+
+	if spec.Data != "" {
+		u, err := url.Parse(spec.Data)
+		if err != nil || u.Scheme == "" {
+			return nil, fmt.Errorf("invalid --data %q: expected a URI with a scheme (https:, tel:, myapp:...)", spec.Data)
+		}
+	}
+	if spec.Type != "" && !strings.Contains(spec.Type, "/") {
+		return nil, fmt.Errorf("invalid --type %q: expected a MIME type like text/plain", spec.Type)
+	}
+
+	if spec.Component != "" {
+		pkg, cls, ok := strings.Cut(spec.Component, "/")
+		if !ok || !javaNameRe.MatchString(pkg) || cls == "" {
+			return nil, fmt.Errorf("invalid --component %q: expected package/class, e.g. com.app/.MainActivity", spec.Component)
+		}
+		if strings.HasPrefix(cls, ".") {
+			cls = pkg + cls
+		}
+		if !javaNameRe.MatchString(cls) {
+			return nil, fmt.Errorf("invalid class %q in --component", cls)
+		}
+		spec.Component = pkg + "/" + cls
+	}
+	if spec.Package != "" && !javaNameRe.MatchString(spec.Package) {
+		return nil, fmt.Errorf("invalid --package %q", spec.Package)
+	}
+
+	for _, e := range extras {
+		x, err := parseIntentExtra(e)
+		if err != nil {
+			return nil, err
+		}
+		spec.Extras = append(spec.Extras, x)
+	}
+
+	if flags != "" {
+		n, err := parseIntentFlags(flags)
+		if err != nil {
+			return nil, err
+		}
+		spec.Flags = n
+	}
+	return spec, nil
+}
+
+// expandIntentName turns a bare upper-case name like VIEW into
+// android.intent.action.VIEW; anything else is left alone.
+func expandIntentName(name, prefix string) string {
+	if shortNameRe.MatchString(name) {
+		return prefix + name
+	}
+	return name
+}
+
+// parseIntentExtra parses key:type=value, or key=value for a string.
+func parseIntentExtra(s string) (intentExtra, error) {
+	keyType, value, ok := strings.Cut(s, "=")
+	if !ok {
+		return intentExtra{}, fmt.Errorf("invalid --extra %q: expected key:type=value", s)
+	}
+	key, typ, hasType := strings.Cut(keyType, ":")
+	if !hasType {
+		typ = "string"
+	}
+	if key == "" {
+		return intentExtra{}, fmt.Errorf("invalid --extra %q: empty key", s)
+	}
+	canonical, ok := extraTypes[strings.ToLower(typ)]
+	if !ok {
+		return intentExtra{}, fmt.Errorf("invalid --extra %q: unknown type %q", s, typ)
+	}
+
+	check := func(v string) error {
+		var err error
+		switch strings.TrimSuffix(canonical, "[]") {
+		case "int":
+			_, err = strconv.ParseInt(v, 10, 32)
+		case "long":
+			_, err = strconv.ParseInt(v, 10, 64)
+		case "float":
+			_, err = strconv.ParseFloat(v, 32)
+		case "double":
+			_, err = strconv.ParseFloat(v, 64)
+		case "bool":
+			if v != "true" && v != "false" {
+				err = fmt.Errorf("expected true or false")
+			}
+		case "uri":
+			var u *url.URL
+			if u, err = url.Parse(v); err == nil && u.Scheme == "" {
+				err = fmt.Errorf("missing scheme")
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("invalid --extra %q: %q is not a valid %s", s, v, strings.TrimSuffix(canonical, "[]"))
+		}
+		return nil
+	}
+	if strings.HasSuffix(canonical, "[]") {
+		if value != "" {
+			for _, v := range strings.Split(value, ",") {
+				if err := check(v); err != nil {
+					return intentExtra{}, err
+				}
+			}
+		}
+	} else if err := check(value); err != nil {
+		return intentExtra{}, err
+	}
+	return intentExtra{Key: key, Type: canonical, Value: value}, nil
+}
+
+// parseIntentFlags accepts a number (0x10000000) or names like
+// NEW_TASK,CLEAR_TOP (FLAG_ACTIVITY_ prefixes are optional). Intent flags
+// are a 32-bit Int on the phone, so a number takes the same bits: values
+// from 0x80000000 up come out negative.
+func parseIntentFlags(s string) (int, error) {
+	if n, err := strconv.ParseUint(s, 0, 32); err == nil {
+		return int(int32(uint32(n))), nil
+	} else if errors.Is(err, strconv.ErrRange) {
+		return 0, fmt.Errorf("intent flags %s do not fit in 32 bits", s)
+	}
+	total := 0
+	for _, name := range strings.Split(s, ",") {
+		key := strings.ToUpper(strings.TrimSpace(name))
+		key = strings.TrimPrefix(strings.TrimPrefix(key, "FLAG_"), "ACTIVITY_")
+		v, ok := intentFlags[key]
+		if !ok {
+			known := make([]string, 0, len(intentFlags))
+			for k := range intentFlags {
+				known = append(known, k)
+			}
+			sort.Strings(known)
+			return 0, fmt.Errorf("unknown intent flag %q — use a number or one of %s", name, strings.Join(known, ", "))
+		}
+		total |= v
+	}
+	return total, nil
+}
+
+// String formats the intent like 'am' does.
+func (s *intentSpec) String() string {
+	var parts []string
+	if s.Action != "" {
+		parts = append(parts, "act="+s.Action)
+	}
+	if len(s.Categories) > 0 {
+		parts = append(parts, "cat=["+strings.Join(s.Categories, ",")+"]")
+	}
+	if s.Data != "" {
+		parts = append(parts, "dat="+s.Data)
+	}
+	if s.Type != "" {
+		parts = append(parts, "typ="+s.Type)
+	}
+	if s.Flags != 0 {
+		parts = append(parts, fmt.Sprintf("flg=0x%x", uint32(s.Flags)))
+	}
+	if s.Package != "" {
+		parts = append(parts, "pkg="+s.Package)
+	}
+	if s.Component != "" {
+		parts = append(parts, "cmp="+s.Component)
+	}
+	if len(s.Extras) > 0 {
+		var ex []string
+		for _, e := range s.Extras {
+			ex = append(ex, fmt.Sprintf("%s:%s=%s", e.Key, e.Type, e.Value))
+		}
+		parts = append(parts, "("+strings.Join(ex, " ")+")")
+	}
+	return "Intent { " + strings.Join(parts, " ") + " }"
+}
@@ -2,9 +2,14 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"sort"
+	"strconv"
 	"strings"
+	"text/tabwriter"
 	"time"
 
+	"github.com/phonessh/psh/history"
 	"github.com/spf13/cobra"
 )
 
@@ -17,7 +22,13 @@ Examples:
   psh notifs                    List recent notifications
   psh notifs --app slack        Filter by app name
   psh notifs --clear slack      Clear Slack notifications
-  psh notifs --clear-all        Clear all notifications`,
+  psh notifs --clear-all        Clear all notifications
+  psh notifs collect            Archive notifications to local history
+  psh notifs history --app slack --since 3d --grep deploy
+  psh notifs stats --since 7d   Notifications per app per day
+  psh notifs snooze --app slack 1h
+  psh notifs mute twitter --for 2h
+  psh notifs muted`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		c, _ := mustConnect()
 		defer c.Close()
@@ -26,6 +37,7 @@ Examples:
 		clear, _ := cmd.Flags().GetString("clear")
 		clearAll, _ := cmd.Flags().GetBool("clear-all")
 		limit, _ := cmd.Flags().GetInt("limit")
+		showKeys, _ := cmd.Flags().GetBool("keys")
 
 		flags := map[string]string{}
 		if app != "" {
@@ -71,6 +83,9 @@ Examples:
 
 			cyan.Printf("  %s", pkg)
 			dim.Printf("  %s\n", t.Format("15:04"))
+			if showKeys {
+				dim.Printf("  key: %s\n", str(notif["key"]))
+			}
 			if title != "" {
 				fmt.Printf("  %s\n", bold.Sprint(title))
 			}
@@ -87,11 +102,412 @@ Examples:
 	},
 }
 
+var notifsSnoozeCmd = &cobra.Command{
+	Use:   "snooze <key|--app name> <duration>",
+	Short: "Snooze a notification, or all of an app's notifications",
+	Long: `Hide notifications for a while; Android re-posts them when the snooze ends.
+
+Use 'psh notifs --keys' to see notification keys.
+
+Examples:
+  psh notifs snooze --app slack 1h
+  psh notifs snooze "0|com.slack|42|null|10123" 30m`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		app, _ := cmd.Flags().GetString("app")
+		if (app == "") != (len(args) == 2) {
+			return fmt.Errorf("usage: psh notifs snooze <key> <duration>  or  psh notifs snooze --app <name> <duration>")
+		}
+		d, err := parseDuration(args[len(args)-1])
+		if err != nil {
+			return err
+		}
+
+		flags := map[string]string{"duration": strconv.FormatInt(d.Milliseconds(), 10)}
+		cmdArgs := []string{"snooze"}
+		if app != "" {
+			flags["app"] = app
+		} else {
+			cmdArgs = append(cmdArgs, args[0])
+		}
+
+		c, _ := mustConnect()
+		defer c.Close()
+
+		data, err := c.RunRaw(newCmd("notifs", cmdArgs, flags))
+		if err != nil {
+			return err
+		}
+		green.Printf("Snoozed %v notification(s) for %s\n", data["snoozed"], d)
+		return nil
+	},
+}
+
+var notifsMuteCmd = &cobra.Command{
+	Use:   "mute <app>",
+	Short: "Suppress an app's notifications, optionally for a limited time",
+	Long: `Mute an app: its current notifications are cleared and new ones are
+dismissed as soon as they arrive. With --for the app unmutes on its own.
+
+Examples:
+  psh notifs mute twitter
+  psh notifs mute slack --for 2h`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		forStr, _ := cmd.Flags().GetString("for")
+		flags := map[string]string{}
+		if forStr != "" {
+			d, err := parseDuration(forStr)
+			if err != nil {
+				return err
+			}
+			// The phone reads a zero or negative --for as "until unmuted"
+			if d.Milliseconds() < 1 {
+				return fmt.Errorf("--for must be at least 1ms, got %s (leave it out to mute until unmuted)", forStr)
+			}
+			flags["for"] = strconv.FormatInt(d.Milliseconds(), 10)
+		}
+
+		c, _ := mustConnect()
+		defer c.Close()
+
+		data, err := c.RunRaw(newCmd("notifs", []string{"mute", args[0]}, flags))
+		if err != nil {
+			return err
+		}
+		green.Printf("Muted %s %s\n", args[0], mutedUntil(data["until"]))
+		if cleared, _ := data["cleared"].(float64); cleared > 0 {
+			dim.Printf("Cleared %d existing notification(s)\n", int(cleared))
+		}
+		return nil
+	},
+}
+
+var notifsUnmuteCmd = &cobra.Command{
+	Use:   "unmute <app>",
+	Short: "Unmute an app muted with 'psh notifs mute'",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, _ := mustConnect()
+		defer c.Close()
+
+		if _, err := c.RunRaw(newCmd("notifs", []string{"unmute", args[0]}, nil)); err != nil {
+			return err
+		}
+		green.Printf("Unmuted %s\n", args[0])
+		return nil
+	},
+}
+
+var notifsMutedCmd = &cobra.Command{
+	Use:   "muted",
+	Short: "List muted apps",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, _ := mustConnect()
+		defer c.Close()
+
+		data, err := c.RunRaw(newCmd("notifs", []string{"muted"}, nil))
+		if err != nil {
+			return err
+		}
+		muted, _ := data["muted"].([]interface{})
+		if len(muted) == 0 {
+			dim.Println("No muted apps")
+			return nil
+		}
+		for _, m := range muted {
+			entry, ok := m.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			cyan.Printf("  %-30s", str(entry["app"]))
+			dim.Printf("%s\n", mutedUntil(entry["until"]))
+		}
+		return nil
+	},
+}
+
+var notifsCollectCmd = &cobra.Command{
+	Use:   "collect",
+	Short: "Continuously archive notifications to the local history database",
+	Long: `Poll the phone for notifications and store every new one in the local
+history database, so it can be searched after it is dismissed on the phone.
+
+Runs until interrupted. Connection errors are retried on the next poll.
+
+Example:
+  psh notifs collect --interval 30s`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		interval, _ := cmd.Flags().GetDuration("interval")
+
+		store, err := history.Open()
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		path, _ := history.Path()
+		dim.Printf("Collecting notifications every %s into %s (Ctrl+C to stop)\n", interval, path)
+
+		for {
+			added, err := collectNotifications(store)
+			if err != nil {
+				red.Fprintf(os.Stderr, "%s  collect failed: %v\n", time.Now().Format("15:04:05"), err)
+			} else if added > 0 {
+				green.Printf("%s  +%d notification(s)\n", time.Now().Format("15:04:05"), added)
+			}
+			time.Sleep(interval)
+		}
+	},
+}
+
+var notifsHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Search archived notifications",
+	Long: `Search notifications archived by 'psh notifs collect', including ones
+already dismissed on the phone.
+
+--since accepts durations like 90m, 12h, 3d, 2w or a date (2006-01-02).
+
+Examples:
+  psh notifs history
+  psh notifs history --app slack --since 3d
+  psh notifs history --grep "verification code" --since 1d`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		q, err := historyQuery(cmd)
+		if err != nil {
+			return err
+		}
+		q.Limit, _ = cmd.Flags().GetInt("limit")
+
+		store, err := history.Open()
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		notifs, err := store.Notifications(q)
+		if err != nil {
+			return err
+		}
+		if len(notifs) == 0 {
+			dim.Println("No archived notifications match")
+			return nil
+		}
+
+		for _, n := range notifs {
+			cyan.Printf("  %s", n.App)
+			dim.Printf("  %s\n", n.Posted.Format("Jan 02 15:04"))
+			if n.Title != "" {
+				fmt.Printf("  %s\n", bold.Sprint(n.Title))
+			}
+			if n.Text != "" {
+				fmt.Printf("  %s\n", n.Text)
+			}
+			fmt.Println()
+		}
+		fmt.Printf("%d notification(s)\n", len(notifs))
+		return nil
+	},
+}
+
+var notifsStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show archived notification counts per app per day",
+	Long: `Summarise the notification history per app per day — useful to find
+which apps are the noisiest.
+
+Examples:
+  psh notifs stats
+  psh notifs stats --since 7d
+  psh notifs stats --app whatsapp --since 30d`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		q, err := historyQuery(cmd)
+		if err != nil {
+			return err
+		}
+
+		store, err := history.Open()
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		counts, err := store.DailyCounts(q)
+		if err != nil {
+			return err
+		}
+		if len(counts) == 0 {
+			dim.Println("No archived notifications match")
+			return nil
+		}
+
+		totals := map[string]int{}
+		days := map[string]bool{}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "DAY\tAPP\tCOUNT\n")
+		for _, dc := range counts {
+			fmt.Fprintf(w, "%s\t%s\t%d\n", dc.Day, dc.App, dc.Count)
+			totals[dc.App] += dc.Count
+			days[dc.Day] = true
+		}
+		w.Flush()
+
+		fmt.Println()
+		bold.Println("Totals")
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "APP\tTOTAL\tPER DAY\n")
+		for _, app := range sortedByCount(totals) {
+			fmt.Fprintf(w, "%s\t%d\t%.1f\n", app, totals[app], float64(totals[app])/float64(len(days)))
+		}
+		w.Flush()
+		return nil
+	},
+}
+
 func init() {
 	notifsCmd.Flags().String("app", "", "filter by app name/package")
 	notifsCmd.Flags().String("clear", "", "clear notifications from this app")
 	notifsCmd.Flags().Bool("clear-all", false, "clear all notifications")
 	notifsCmd.Flags().Int("limit", 50, "max notifications to show")
+	notifsCmd.Flags().Bool("keys", false, "show notification keys (for snooze)")
+
+	notifsSnoozeCmd.Flags().String("app", "", "snooze all notifications from this app")
+	notifsMuteCmd.Flags().String("for", "", "unmute automatically after this long (e.g. 30m, 2h, 1d)")
+
+	notifsCollectCmd.Flags().Duration("interval", 30*time.Second, "how often to poll the phone")
+
+	notifsHistoryCmd.Flags().String("app", "", "filter by app name/package")
+	notifsHistoryCmd.Flags().String("since", "", "only show notifications newer than this (e.g. 3d, 12h, 2006-01-02)")
+	notifsHistoryCmd.Flags().String("grep", "", "only show notifications whose title or text contains this")
+	notifsHistoryCmd.Flags().Int("limit", 100, "max notifications to show")
+
+	notifsStatsCmd.Flags().String("app", "", "filter by app name/package")
+	notifsStatsCmd.Flags().String("since", "7d", "only count notifications newer than this")
+	notifsStatsCmd.Flags().String("grep", "", "only count notifications whose title or text contains this")
+
+	notifsCmd.AddCommand(notifsSnoozeCmd)
+	notifsCmd.AddCommand(notifsMuteCmd)
+	notifsCmd.AddCommand(notifsUnmuteCmd)
+	notifsCmd.AddCommand(notifsMutedCmd)
+	notifsCmd.AddCommand(notifsCollectCmd)
+	notifsCmd.AddCommand(notifsHistoryCmd)
+	notifsCmd.AddCommand(notifsStatsCmd)
+}
+
+// collectNotifications fetches the phone's current notifications and archives
+// any that are not yet in the store.
+func collectNotifications(store *history.Store) (int, error) {
+	c, dev, err := getClient()
+	if err != nil {
+		return 0, err
+	}
+	defer c.Close()
+
+	data, err := c.RunRaw(newCmd("notifs", nil, map[string]string{"limit": "200"}))
+	if err != nil {
+		return 0, err
+	}
+	return store.AddNotifications(notifsFromData(dev.Name, data))
+}
+
+// notifsFromData converts a "notifs" response into history records.
+func notifsFromData(device string, data map[string]interface{}) []history.Notification {
+	items, _ := data["notifications"].([]interface{})
+	out := make([]history.Notification, 0, len(items))
+	for _, item := range items {
+		n, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		ts, _ := n["time"].(float64)
+		out = append(out, history.Notification{
+			Device: device,
+			Key:    str(n["key"]),
+			App:    str(n["app"]),
+			Title:  str(n["title"]),
+			Text:   str(n["text"]),
+			Posted: time.UnixMilli(int64(ts)),
+		})
+	}
+	return out
+}
+
+func historyQuery(cmd *cobra.Command) (history.Query, error) {
+	var q history.Query
+	q.App, _ = cmd.Flags().GetString("app")
+	q.Grep, _ = cmd.Flags().GetString("grep")
+	since, _ := cmd.Flags().GetString("since")
+	if since != "" {
+		t, err := parseSince(since)
+		if err != nil {
+			return q, err
+		}
+		q.Since = t
+	}
+	return q, nil
+}
+
+// parseSince turns "90m", "12h", "3d", "2w" or a YYYY-MM-DD date into a
+// point in time in the past.
+func parseSince(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	d, err := parseDuration(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --since %q — use e.g. 90m, 12h, 3d, 2w or 2006-01-02", s)
+	}
+	return time.Now().Add(-d), nil
+}
+
+// parseDuration is time.ParseDuration plus whole days ("3d") and weeks ("2w").
+func parseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if n := len(s); n > 1 && (s[n-1] == 'd' || s[n-1] == 'w') {
+		if v, err := strconv.Atoi(s[:n-1]); err == nil {
+			days := time.Duration(v)
+			if s[n-1] == 'w' {
+				days *= 7
+			}
+			return days * 24 * time.Hour, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q — use e.g. 30s, 90m, 12h, 3d", s)
+	}
+	return d, nil
+}
+
+// mutedUntil describes a muted-until epoch-millis value (0 = indefinitely).
+func mutedUntil(v interface{}) string {
+	until, _ := v.(float64)
+	if until == 0 {
+		return "until unmuted"
+	}
+	t := time.UnixMilli(int64(until))
+	return fmt.Sprintf("until %s (%s left)", t.Format("Jan 02 15:04"), time.Until(t).Round(time.Minute))
+}
+
+// sortedByCount returns the map keys ordered by descending count.
+func sortedByCount(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if m[keys[i]] != m[keys[j]] {
+			return m[keys[i]] > m[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
 }
 
 func str(v interface{}) string {
@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/phonessh/psh/client"
+	"github.com/phonessh/psh/otp"
+	"github.com/spf13/cobra"
+)
+
+var otpCmd = &cobra.Command{
+	Use:   "otp",
+	Short: "Print the latest one-time code from SMS or notifications",
+	Long: `Find one-time passcodes (2FA / verification codes) in recent SMS and
+notifications and print the freshest one.
+
+Without --wait, codes received within --max-age are considered.
+With --wait, psh ignores codes already on the phone and waits for a new one.
+
+The code alone is printed to stdout, so it can be used in scripts:
+  CODE=$(psh otp --wait 60s)
+
+Examples:
+  psh otp
+  psh otp --wait 60s --copy
+  psh otp --from google`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		wait, _ := cmd.Flags().GetDuration("wait")
+		maxAge, _ := cmd.Flags().GetDuration("max-age")
+		interval, _ := cmd.Flags().GetDuration("interval")
+		from, _ := cmd.Flags().GetString("from")
+		copyCode, _ := cmd.Flags().GetBool("copy")
+
+		c, _ := mustConnect()
+		defer c.Close()
+
+		start := time.Now()
+		found, err := scanOTPs(c, from)
+		if err != nil {
+			return err
+		}
+
+		var best *otpHit
+		if wait == 0 {
+			best = freshestOTP(found, start.Add(-maxAge), nil)
+		} else {
+			ignore := map[string]bool{}
+			for _, h := range found {
+				ignore[h.id()] = true
+			}
+			dim.Fprintf(os.Stderr, "Waiting up to %s for a new code...\n", wait)
+			deadline := start.Add(wait)
+			for best == nil && time.Now().Before(deadline) {
+				time.Sleep(interval)
+				found, err = scanOTPs(c, from)
+				if err != nil {
+					return err
+				}
+				best = freshestOTP(found, time.Time{}, ignore)
+			}
+		}
+
+		if best == nil {
+			if wait > 0 {
+				return fmt.Errorf("no new code arrived within %s", wait)
+			}
+			return fmt.Errorf("no code found in the last %s — try --wait 60s", maxAge)
+		}
+
+		fmt.Println(best.Code)
+		dim.Fprintf(os.Stderr, "from %s (%s) at %s\n", best.Source, best.Sender, best.Time.Format("15:04:05"))
+		if copyCode {
+			if err := copyToLocalClipboard(best.Code); err != nil {
+				return fmt.Errorf("copying to clipboard: %w", err)
+			}
+			green.Fprintln(os.Stderr, "Copied to clipboard")
+		}
+		return nil
+	},
+}
+
+func init() {
+	otpCmd.Flags().Duration("wait", 0, "wait up to this long for a new code to arrive")
+	otpCmd.Flags().Duration("max-age", 10*time.Minute, "without --wait, ignore codes older than this")
+	otpCmd.Flags().Duration("interval", 2*time.Second, "poll interval while waiting")
+	otpCmd.Flags().String("from", "", "only consider SMS senders or notification apps containing this")
+	otpCmd.Flags().Bool("copy", false, "copy the code to this computer's clipboard")
+}
+
+type otpHit struct {
+	otp.Match
+	Source string // "sms" or "notification"
+	Sender string // phone number or app package
+	Time   time.Time
+}
+
+func (h otpHit) id() string {
+	return h.Source + "|" + h.Sender + "|" + h.Code + "|" + h.Time.String()
+}
+
+// scanOTPs looks for codes in unread SMS and current notifications.
+func scanOTPs(c *client.Client, from string) ([]otpHit, error) {
+	var hits []otpHit
+	from = strings.ToLower(from)
+
+	smsData, smsErr := c.RunRaw(newCmd("sms", []string{"list"}, map[string]string{"unread": "true", "limit": "20"}))
+	if smsErr == nil {
+		msgs, _ := smsData["messages"].([]interface{})
+		for _, m := range msgs {
+			msg, ok := m.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			sender := str(msg["from"])
+			if from != "" && !strings.Contains(strings.ToLower(sender), from) {
+				continue
+			}
+			if match, ok := otp.Extract(str(msg["body"])); ok {
+				ts, _ := msg["time"].(float64)
+				hits = append(hits, otpHit{Match: match, Source: "sms", Sender: sender, Time: time.UnixMilli(int64(ts))})
+			}
+		}
+	}
+
+	notifData, notifErr := c.RunRaw(newCmd("notifs", nil, map[string]string{"limit": "100"}))
+	if notifErr == nil {
+		for _, n := range notifsFromData("", notifData) {
+			if from != "" && !strings.Contains(strings.ToLower(n.App), from) {
+				continue
+			}
+			if match, ok := otp.Extract(n.Title + "\n" + n.Text); ok {
+				hits = append(hits, otpHit{Match: match, Source: "notification", Sender: n.App, Time: n.Posted})
+			}
+		}
+	}
+
+	if smsErr != nil && notifErr != nil {
+		return nil, fmt.Errorf("reading SMS: %v; reading notifications: %v", smsErr, notifErr)
+	}
+	return hits, nil
+}
+
+// freshestOTP returns the newest hit received after since and not in ignore.
+func freshestOTP(hits []otpHit, since time.Time, ignore map[string]bool) *otpHit {
+	var best *otpHit
+	for i := range hits {
+		h := &hits[i]
+		if h.Time.Before(since) || ignore[h.id()] {
+			continue
+		}
+		if best == nil || h.Time.After(best.Time) {
+			best = h
+		}
+	}
+	return best
+}
+
+// copyToLocalClipboard puts text on the laptop clipboard using whichever
+// platform tool is available.
+func copyToLocalClipboard(text string) error {
+	var candidates [][]string
+	switch runtime.GOOS {
+	case "darwin":
+		candidates = [][]string{{"pbcopy"}}
+	case "windows":
+		candidates = [][]string{{"clip"}}
+	default:
+		candidates = [][]string{
+			{"wl-copy"},
+			{"xclip", "-selection", "clipboard"},
+			{"xsel", "--clipboard", "--input"},
+		}
+	}
+	for _, argv := range candidates {
+		if _, err := exec.LookPath(argv[0]); err != nil {
+			continue
+		}
+		c := exec.Command(argv[0], argv[1:]...)
+		c.Stdin = strings.NewReader(text)
+		return c.Run()
+	}
+	return fmt.Errorf("no clipboard tool found (tried %s)", candidateNames(candidates))
+}
+
+func candidateNames(candidates [][]string) string {
+	names := make([]string, len(candidates))
+	for i, c := range candidates {
+		names[i] = c[0]
+	}
+	return strings.Join(names, ", ")
+}
@@ -1,8 +1,11 @@
 package cmd
 
 import (
+	"bufio"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/fatih/color"
@@ -15,6 +18,7 @@ var (
 	flagHost   string
 	flagPort   int
 	flagToken  string
+	flagNoInput bool
 )
 
 var bold  = color.New(color.Bold)
@@ -55,6 +59,7 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&flagHost, "host", "", "override phone IP/hostname")
 	rootCmd.PersistentFlags().IntVar(&flagPort, "port", 8765, "override port")
 	rootCmd.PersistentFlags().StringVar(&flagToken, "token", "", "override auth token")
+	rootCmd.PersistentFlags().BoolVar(&flagNoInput, "no-input", false, "never prompt; fail when a name is ambiguous")
 
 	rootCmd.AddCommand(pairCmd)
 	rootCmd.AddCommand(devicesCmd)
@@ -69,6 +74,9 @@ func init() {
 	rootCmd.AddCommand(rmCmd)
 	rootCmd.AddCommand(notifsCmd)
 	rootCmd.AddCommand(smsCmd)
+	rootCmd.AddCommand(otpCmd)
+	rootCmd.AddCommand(contactsCmd)
+	rootCmd.AddCommand(callsCmd)
 	rootCmd.AddCommand(appsCmd)
 	rootCmd.AddCommand(volumeCmd)
 	rootCmd.AddCommand(brightnessCmd)
@@ -78,44 +86,50 @@ func init() {
 	rootCmd.AddCommand(shellCmd)
 	rootCmd.AddCommand(clickCmd)
 	rootCmd.AddCommand(uiCmd)
+	rootCmd.AddCommand(flowCmd)
 	rootCmd.AddCommand(openCmd)
+	rootCmd.AddCommand(intentCmd)
+	rootCmd.AddCommand(shareCmd)
 	rootCmd.AddCommand(tapCmd)
 	rootCmd.AddCommand(swipeCmd)
 	rootCmd.AddCommand(typeCmd)
 	rootCmd.AddCommand(keyCmd)
 	rootCmd.AddCommand(aiCmd)
+	rootCmd.AddCommand(agentCmd)
 }
 
 // getClient loads config and connects to the phone.
 func getClient() (*client.Client, *client.Device, error) {
+	dev, err := selectedDevice()
+	if err != nil {
+		return nil, nil, err
+	}
+	c, err := client.Connect(dev)
+	return c, dev, err
+}
+
+// selectedDevice returns the device chosen by --host/--token or --device,
+// without connecting to it.
+func selectedDevice() (*client.Device, error) {
 	// Override via flags
 	if flagHost != "" {
 		token := flagToken
 		if token == "" {
-			return nil, nil, fmt.Errorf("--host requires --token")
+			return nil, fmt.Errorf("--host requires --token")
 		}
-		dev := &client.Device{
+		return &client.Device{
 			Name:  "override",
 			Host:  flagHost,
 			Port:  flagPort,
 			Token: token,
-		}
-		c, err := client.Connect(dev)
-		return c, dev, err
+		}, nil
 	}
 
 	cfg, err := client.LoadConfig()
 	if err != nil {
-		return nil, nil, fmt.Errorf("loading config: %w", err)
-	}
-
-	dev, err := cfg.GetDevice(flagDevice)
-	if err != nil {
-		return nil, nil, err
+		return nil, fmt.Errorf("loading config: %w", err)
 	}
-
-	c, err := client.Connect(dev)
-	return c, dev, err
+	return cfg.GetDevice(flagDevice)
 }
 
 // mustConnect is a helper that exits on error.
@@ -143,3 +157,35 @@ func die(format string, args ...interface{}) {
 	red.Fprintf(os.Stderr, "Error: "+format+"\n", args...)
 	os.Exit(1)
 }
+
+// pickOne shows a numbered list on stderr and reads a choice from stdin.
+// It fails instead of prompting with --no-input or when stdin is not a terminal.
+func pickOne(prompt string, options []string) (int, error) {
+	if flagNoInput || !stdinIsTerminal() {
+		return 0, fmt.Errorf("%s\n  %s\nbe more specific", strings.TrimSuffix(prompt, ":"), strings.Join(options, "\n  "))
+	}
+	fmt.Fprintln(os.Stderr, prompt)
+	for i, o := range options {
+		fmt.Fprintf(os.Stderr, "  %s %s\n", cyan.Sprintf("%2d)", i+1), o)
+	}
+	in := bufio.NewReader(os.Stdin)
+	for {
+		fmt.Fprintf(os.Stderr, "Choose 1-%d (empty to cancel): ", len(options))
+		line, err := in.ReadString('\n')
+		line = strings.TrimSpace(line)
+		if line == "" {
+			return 0, fmt.Errorf("cancelled")
+		}
+		if n, convErr := strconv.Atoi(line); convErr == nil && n >= 1 && n <= len(options) {
+			return n - 1, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("cancelled")
+		}
+	}
+}
+
+func stdinIsTerminal() bool {
+	fi, err := os.Stdin.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
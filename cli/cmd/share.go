@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var shareCmd = &cobra.Command{
+	Use:   "share <file>... | --text [text...]",
+	Short: "Send files or text from this computer to an app on the phone",
+	Long: `Share local files or text into a phone app, as if picked from its share
+sheet. Files are uploaded to ` + phoneStagingDir + ` (kept for a day so the app
+can read them) and handed over with ACTION_SEND / ACTION_SEND_MULTIPLE.
+
+Without --to, the phone's share sheet opens to pick the app.
+With --text, the arguments are the text; without arguments it is read from stdin.
+
+Examples:
+  psh share ./report.pdf --to whatsapp
+  psh share ./a.jpg ./b.jpg --to com.google.android.gm --subject "Photos"
+  psh share ./build.apk --message "latest build"
+  echo hi | psh share --text
+  psh share --text "https://example.com/pr/42" --to slack`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		isText, _ := cmd.Flags().GetBool("text")
+		message, _ := cmd.Flags().GetString("message")
+		to, _ := cmd.Flags().GetString("to")
+		flags := map[string]string{}
+		for _, f := range []string{"subject", "type"} {
+			if v, _ := cmd.Flags().GetString(f); v != "" {
+				flags[f] = v
+			}
+		}
+
+		var files []string
+		if isText {
+			text := joinArgs(args)
+			if len(args) == 0 {
+				if stdinIsTerminal() {
+					return fmt.Errorf("no text given — pass it as arguments or pipe it in")
+				}
+				b, err := io.ReadAll(os.Stdin)
+				if err != nil {
+					return err
+				}
+				text = strings.TrimRight(string(b), "\n")
+			}
+			if text == "" {
+				return fmt.Errorf("nothing to share")
+			}
+			flags["text"] = text
+		} else {
+			if len(args) == 0 {
+				return fmt.Errorf("give files to share, or --text")
+			}
+			for _, a := range args {
+				info, err := os.Stat(a)
+				if err != nil {
+					return err
+				}
+				if info.IsDir() {
+					return fmt.Errorf("%s is a directory", a)
+				}
+			}
+			files = args
+			if message != "" {
+				flags["text"] = message
+			}
+		}
+
+		c, _ := mustConnect()
+		defer c.Close()
+
+		if to != "" {
+			pkg, _, err := resolveApp(c, to)
+			if err != nil {
+				return err
+			}
+			flags["to"] = pkg
+		}
+
+		var remote []string
+		dir := fmt.Sprintf("%s/share-%d", phoneStagingDir, time.Now().UnixNano())
+		used := map[string]bool{}
+		for _, f := range files {
+			data, err := os.ReadFile(f)
+			if err != nil {
+				return err
+			}
+			name := filepath.Base(f)
+			ext := filepath.Ext(name)
+			for n := 1; used[name]; n++ {
+				name = fmt.Sprintf("%s-%d%s", strings.TrimSuffix(filepath.Base(f), ext), n, ext)
+			}
+			used[name] = true
+			path := dir + "/" + name
+			if err := uploadBytes(c, data, path, name); err != nil {
+				return err
+			}
+			remote = append(remote, path)
+		}
+
+		data, err := c.RunRaw(newCmd("share", remote, flags))
+		if err != nil {
+			return err
+		}
+		what := "text"
+		if len(files) > 0 {
+			what = fmt.Sprintf("%d file(s)", len(files))
+		}
+		if pkg := str(data["to"]); pkg != "" {
+			green.Printf("Shared %s with %s\n", what, pkg)
+		} else {
+			green.Printf("Share sheet opened for %s — pick the app on the phone\n", what)
+		}
+		return nil
+	},
+}
+
+func init() {
+	shareCmd.Flags().Bool("text", false, "share text (arguments or stdin) instead of files")
+	shareCmd.Flags().String("to", "", "target app (name or package); default shows the share sheet")
+	shareCmd.Flags().String("message", "", "text to send along with the files")
+	shareCmd.Flags().String("subject", "", "subject, for email apps")
+	shareCmd.Flags().String("type", "", "override the MIME type (default: from file extensions)")
+}
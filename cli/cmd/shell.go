@@ -128,24 +128,7 @@ Use ↑/↓ arrow keys for command history.`,
 			subCmd := parts[0]
 			cmdArgs := parts[1:]
 
-			flags := map[string]string{}
-			pureArgs := []string{}
-			i := 0
-			for i < len(cmdArgs) {
-				if strings.HasPrefix(cmdArgs[i], "--") {
-					key := strings.TrimPrefix(cmdArgs[i], "--")
-					if i+1 < len(cmdArgs) && !strings.HasPrefix(cmdArgs[i+1], "--") {
-						flags[key] = cmdArgs[i+1]
-						i += 2
-					} else {
-						flags[key] = "true"
-						i++
-					}
-				} else {
-					pureArgs = append(pureArgs, cmdArgs[i])
-					i++
-				}
-			}
+			pureArgs, flags := splitFlags(cmdArgs)
 
 			msg := client.CmdMsg{
 				Type:  "cmd",
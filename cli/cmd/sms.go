@@ -2,7 +2,11 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/spf13/cobra"
 )
@@ -17,7 +21,8 @@ Examples:
   psh sms list --unread      Show only unread messages
   psh sms list --from +1234  Filter by sender
   psh sms send +1234567890 "Running late"
-  psh sms conversations      Show conversation threads`,
+  psh sms conversations      Show conversation threads
+  psh sms thread thread:42   Read a whole conversation`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Default: list
 		return smsListRun(cmd, []string{"list"})
@@ -31,18 +36,81 @@ var smsListCmd = &cobra.Command{
 }
 
 var smsSendCmd = &cobra.Command{
-	Use:   "send <number> <message>",
+	Use:   "send <number|contact> <message> | --to <number|contact>... [--attach <file>]... <message>",
 	Short: "Send an SMS message",
-	Args:  cobra.MinimumNArgs(2),
+	Long: `Send an SMS message to a phone number or a contact name.
+
+Names are fuzzy-matched against your contacts; if several contacts or numbers
+match, you are asked to pick one.
+
+With --at or --in the message is scheduled instead of sent now. By default
+it is kept on this computer and sent by 'psh agent', which must be running
+at that time. With --on-phone it is handed to the phone, which sends it even
+if this computer is asleep. See 'psh sms scheduled'.
+
+--at accepts "2006-01-02 15:04", an RFC 3339 time, or "15:04" (the next
+time that clock time comes round).
+
+Several --to recipients, or any --attach, send an MMS instead: a group
+message to everyone, with the files attached.
+
+Examples:
+  psh sms send +15551234567 "Running late"
+  psh sms send Alice "Running late"
+  psh sms send --at "2026-10-17 09:00" Alice "Pickup is today at 10"
+  psh sms send --in 2h --on-phone +15551234567 "Call me back"
+  psh sms send --to Alice --to Bob --attach ./map.jpg "Meet here at 10"`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		to, _ := cmd.Flags().GetStringArray("to")
+		attach, _ := cmd.Flags().GetStringArray("attach")
+		switch {
+		case len(to) == 0 && len(args) < 2:
+			return fmt.Errorf("usage: psh sms send <number|contact> <message>, or --to <number|contact> ... <message>")
+		case len(to) > 0 && len(args) == 0 && len(attach) == 0:
+			return fmt.Errorf("message cannot be empty")
+		}
+		return nil
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
-		c, _ := mustConnect()
+		to, _ := cmd.Flags().GetStringArray("to")
+		attach, _ := cmd.Flags().GetStringArray("attach")
+		if len(to) == 0 {
+			to, args = args[:1], args[1:]
+		}
+
+		c, dev := mustConnect()
 		defer c.Close()
 
-		number := args[0]
-		message := joinArgs(args[1:])
+		var numbers, labels []string
+		for _, target := range to {
+			number, label, err := resolveRecipient(c, dev, target)
+			if err != nil {
+				return err
+			}
+			numbers = append(numbers, number)
+			labels = append(labels, label)
+		}
+		number, label := numbers[0], labels[0]
+		message := joinArgs(args)
+
+		if len(numbers) > 1 || len(attach) > 0 {
+			if at, _ := sendAt(cmd); !at.IsZero() {
+				return fmt.Errorf("scheduling is only supported for plain SMS to one recipient")
+			}
+			return sendMMS(c, numbers, labels, message, attach)
+		}
 
-		fmt.Printf("Sending to %s: %q\n", number, message)
-		data, err := c.RunRaw(newCmd("sms", append([]string{"send"}, args...), nil))
+		at, err := sendAt(cmd)
+		if err != nil {
+			return err
+		}
+		if !at.IsZero() {
+			onPhone, _ := cmd.Flags().GetBool("on-phone")
+			return scheduleSMS(c, dev, number, label, message, at, onPhone)
+		}
+
+		fmt.Printf("Sending to %s: %q\n", label, message)
+		data, err := c.RunRaw(newCmd("sms", []string{"send", number, message}, nil))
 		if err != nil {
 			return err
 		}
@@ -55,7 +123,7 @@ var smsConversationsCmd = &cobra.Command{
 	Use:   "conversations",
 	Short: "List SMS conversation threads",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		c, _ := mustConnect()
+		c, dev := mustConnect()
 		defer c.Close()
 
 		data, err := c.RunRaw(newCmd("sms", []string{"conversations"}, nil))
@@ -68,6 +136,7 @@ var smsConversationsCmd = &cobra.Command{
 			dim.Println("No conversations")
 			return nil
 		}
+		names := loadContactNames(c, dev)
 
 		for _, cv := range convos {
 			convo, ok := cv.(map[string]interface{})
@@ -76,8 +145,9 @@ var smsConversationsCmd = &cobra.Command{
 			}
 			ts := int64(convo["date"].(float64))
 			t := time.Unix(ts/1000, 0)
-			fmt.Printf("Thread %-6v  %s  %s\n",
+			fmt.Printf("Thread %-6v  %-20s  %s  %s\n",
 				convo["thread_id"],
+				cyan.Sprint(names.label(str(convo["address"]))),
 				dim.Sprint(t.Format("Jan 02")),
 				str(convo["snippet"]))
 		}
@@ -85,18 +155,138 @@ var smsConversationsCmd = &cobra.Command{
 	},
 }
 
+var smsThreadCmd = &cobra.Command{
+	Use:   "thread <thread:id|number|contact-name>",
+	Short: "Show a whole SMS conversation",
+	Long: `Show an SMS conversation oldest-to-newest, chat style: received messages
+on the left (←), sent messages on the right (→).
+
+The target can be thread:<id> with an ID from 'psh sms conversations', a
+phone number or short code, or a contact name. A bare number is looked up
+as a phone number first and only then as a thread ID.
+
+--before pages back through older messages; it accepts an epoch-millis
+timestamp (as printed at the top of each page), a date/time
+("2006-01-02" or "2006-01-02 15:04"), or an age like 3d.
+
+Examples:
+  psh sms thread thread:42
+  psh sms thread +15551234567 --limit 100
+  psh sms thread 72633
+  psh sms thread "Alice" --mark-read
+  psh sms thread thread:42 --before 1697520000000 --before-id sms:9120`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		flags := map[string]string{}
+		if limit, _ := cmd.Flags().GetInt("limit"); limit > 0 {
+			flags["limit"] = strconv.Itoa(limit)
+		}
+		if before, _ := cmd.Flags().GetString("before"); before != "" {
+			t, err := parseBefore(before)
+			if err != nil {
+				return err
+			}
+			flags["before"] = strconv.FormatInt(t.UnixMilli(), 10)
+			if cursor, _ := cmd.Flags().GetString("before-id"); cursor != "" {
+				kind, id, ok := strings.Cut(cursor, ":")
+				if !ok {
+					kind, id = "sms", cursor
+				}
+				if _, err := strconv.ParseInt(id, 10, 64); err != nil || (kind != "sms" && kind != "mms") {
+					return fmt.Errorf("--before-id takes sms:<id> or mms:<id>, got %q", cursor)
+				}
+				flags["before-kind"], flags["before-id"] = kind, id
+			}
+		}
+		if markRead, _ := cmd.Flags().GetBool("mark-read"); markRead {
+			flags["mark-read"] = "true"
+		}
+
+		c, dev := mustConnect()
+		defer c.Close()
+
+		data, err := c.RunRaw(newCmd("sms", append([]string{"thread"}, args...), flags))
+		if err != nil {
+			return err
+		}
+
+		msgs, _ := data["messages"].([]interface{})
+		bold.Printf("Thread %v", data["thread_id"])
+		dim.Printf("  %v\n", data["address"])
+		if len(msgs) == 0 {
+			dim.Println("No messages")
+			return nil
+		}
+
+		if hasMore, _ := data["has_more"].(bool); hasMore {
+			oldest, _ := msgs[len(msgs)-1].(map[string]interface{})
+			ts, _ := oldest["time"].(float64)
+			next := fmt.Sprintf("psh sms thread thread:%v --before %d", data["thread_id"], int64(ts))
+			kind := "sms"
+			if oldest["kind"] == "mms" {
+				kind = "mms"
+			}
+			id, _ := oldest["id"].(float64)
+			next += fmt.Sprintf(" --before-id %s:%d", kind, int64(id))
+			dim.Printf("  … older messages: %s\n", next)
+		}
+		fmt.Println()
+
+		names := loadContactNames(c, dev)
+		width := termWidth()
+		lastDay := ""
+		// Daemon returns newest first; print oldest first like a chat
+		for i := len(msgs) - 1; i >= 0; i-- {
+			msg, ok := msgs[i].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			ts, _ := msg["time"].(float64)
+			t := time.UnixMilli(int64(ts))
+			if day := t.Format("Mon Jan 02 2006"); day != lastDay {
+				dim.Printf("%s\n", centerText("── "+day+" ──", width))
+				lastDay = day
+			}
+			read, _ := msg["read"].(bool)
+			body := str(msg["body"])
+			if str(msg["kind"]) == "mms" {
+				body = mmsText(msg, names)
+			}
+			printBubble(body, t, str(msg["type"]) == "sent", !read, width)
+		}
+
+		if marked, _ := data["marked_read"].(float64); marked > 0 {
+			dim.Printf("\nMarked %d message(s) read\n", int(marked))
+		}
+		return nil
+	},
+}
+
 func init() {
 	smsListCmd.Flags().Bool("unread", false, "show only unread messages")
 	smsListCmd.Flags().String("from", "", "filter by sender number")
 	smsListCmd.Flags().Int("limit", 30, "max messages to show")
+	smsListCmd.Flags().Bool("no-mms", false, "only list plain SMS")
+
+	smsSendCmd.Flags().String("at", "", "schedule for this time instead of sending now")
+	smsSendCmd.Flags().String("in", "", "schedule after this delay, e.g. 30m, 2h, 1d")
+	smsSendCmd.Flags().Bool("on-phone", false, "keep the scheduled message on the phone instead of this computer")
+	smsSendCmd.Flags().StringArray("to", nil, "recipient number or contact (repeat for a group MMS)")
+	smsSendCmd.Flags().StringArray("attach", nil, "file to attach as MMS (repeatable)")
+
+	smsThreadCmd.Flags().Int("limit", 50, "max messages per page")
+	smsThreadCmd.Flags().String("before", "", "only show messages older than this (epoch ms, date, or age like 3d)")
+	smsThreadCmd.Flags().String("before-id", "", "with --before, the sms:<id> or mms:<id> of the last message seen, to page past messages at the same time")
+	smsThreadCmd.Flags().Bool("mark-read", false, "mark the conversation as read (needs PhoneSSH as default SMS app)")
 
 	smsCmd.AddCommand(smsListCmd)
 	smsCmd.AddCommand(smsSendCmd)
 	smsCmd.AddCommand(smsConversationsCmd)
+	smsCmd.AddCommand(smsThreadCmd)
 }
 
 func smsListRun(cmd *cobra.Command, args []string) error {
-	c, _ := mustConnect()
+	c, dev := mustConnect()
 	defer c.Close()
 
 	flags := map[string]string{}
@@ -106,6 +296,12 @@ func smsListRun(cmd *cobra.Command, args []string) error {
 	if from, _ := cmd.Flags().GetString("from"); from != "" {
 		flags["from"] = from
 	}
+	if limit, _ := cmd.Flags().GetInt("limit"); limit > 0 {
+		flags["limit"] = strconv.Itoa(limit)
+	}
+	if noMMS, _ := cmd.Flags().GetBool("no-mms"); !noMMS {
+		flags["mms"] = "true"
+	}
 
 	data, err := c.RunRaw(newCmd("sms", []string{"list"}, flags))
 	if err != nil {
@@ -118,14 +314,19 @@ func smsListRun(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	names := loadContactNames(c, dev)
 	fmt.Printf("%v message(s):\n\n", data["count"])
 	for _, m := range msgs {
 		msg, ok := m.(map[string]interface{})
 		if !ok {
 			continue
 		}
-		from := str(msg["from"])
+		from := names.label(str(msg["from"]))
 		body := str(msg["body"])
+		if str(msg["kind"]) == "mms" {
+			from = mmsRecipients(msg, names)
+			body = strings.ReplaceAll(mmsText(msg, nil), "\n", " ")
+		}
 		ts := int64(msg["time"].(float64))
 		t := time.Unix(ts/1000, 0)
 		msgType := str(msg["type"])
@@ -143,7 +344,7 @@ func smsListRun(cmd *cobra.Command, args []string) error {
 			body = body[:97] + "..."
 		}
 
-		fmt.Printf("%s%-16s  %s  %s\n",
+		fmt.Printf("%s%-20s  %s  %s\n",
 			prefix,
 			cyan.Sprint(from),
 			dim.Sprint(t.Format("Jan 02 15:04")),
@@ -163,3 +364,98 @@ func joinArgs(args []string) string {
 	}
 	return result
 }
+
+// printBubble renders one message chat-style: received on the left, sent
+// right-aligned. Unread received messages get a ● marker.
+func printBubble(body string, t time.Time, sent, unread bool, width int) {
+	bubbleWidth := width * 2 / 3
+	lines := wrapText(body, bubbleWidth-4)
+
+	stamp := t.Format("15:04")
+	if sent {
+		for _, l := range lines {
+			pad := width - utf8.RuneCountInString(l) - 2
+			if pad < 0 {
+				pad = 0
+			}
+			fmt.Printf("%s%s\n", strings.Repeat(" ", pad), green.Sprint(l))
+		}
+		meta := stamp + " →"
+		fmt.Printf("%s%s\n\n", strings.Repeat(" ", max(width-utf8.RuneCountInString(meta)-2, 0)), dim.Sprint(meta))
+		return
+	}
+
+	marker := "←"
+	if unread {
+		marker = "●"
+	}
+	for _, l := range lines {
+		fmt.Printf("  %s\n", cyan.Sprint(l))
+	}
+	dim.Printf("%s %s\n\n", marker, stamp)
+}
+
+// wrapText breaks s into lines of at most width runes, on word boundaries
+// where possible. Existing newlines are kept.
+func wrapText(s string, width int) []string {
+	if width < 10 {
+		width = 10
+	}
+	var out []string
+	for _, para := range strings.Split(s, "\n") {
+		line := ""
+		for _, word := range strings.Fields(para) {
+			for utf8.RuneCountInString(word) > width {
+				if line != "" {
+					out = append(out, line)
+					line = ""
+				}
+				r := []rune(word)
+				out = append(out, string(r[:width]))
+				word = string(r[width:])
+			}
+			switch {
+			case line == "":
+				line = word
+			case utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) <= width:
+				line += " " + word
+			default:
+				out = append(out, line)
+				line = word
+			}
+		}
+		out = append(out, line)
+	}
+	return out
+}
+
+func centerText(s string, width int) string {
+	pad := (width - utf8.RuneCountInString(s)) / 2
+	if pad < 0 {
+		pad = 0
+	}
+	return strings.Repeat(" ", pad) + s
+}
+
+// termWidth uses $COLUMNS when set, otherwise assumes 80 columns.
+func termWidth() int {
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n >= 40 {
+		return n
+	}
+	return 80
+}
+
+// parseBefore accepts epoch millis, "2006-01-02[ 15:04]", or an age like 3d.
+func parseBefore(s string) (time.Time, error) {
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil && ms > 1e11 {
+		return time.UnixMilli(ms), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04", s, time.Local); err == nil {
+		return t, nil
+	}
+	t, err := parseSince(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --before %q — use epoch ms, 2006-01-02 [15:04], or an age like 3d", s)
+	}
+	return t, nil
+}
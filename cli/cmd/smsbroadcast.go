@@ -0,0 +1,385 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/phonessh/psh/client"
+	"github.com/spf13/cobra"
+)
+
+// broadcastRecipient is one CSV row with its rendered message.
+type broadcastRecipient struct {
+	Row     int // 1-based data row in the CSV, for error messages
+	Number  string
+	Label   string
+	Message string
+}
+
+var smsBroadcastCmd = &cobra.Command{
+	Use:   "broadcast",
+	Short: "Send a templated SMS to every recipient in a CSV file",
+	Long: `Mail-merge SMS: render a Go text/template once per CSV row and send it.
+
+The CSV must have a header row. Each column is available to the template by
+its header name, e.g. {{.Name}}. The recipient is taken from --to-column
+(default: the first column named number, phone, mobile or to); it may be a
+phone number or a contact name.
+
+Every message is rendered before anything is sent, so template errors stop
+the run up front. A preview is shown and you are asked to confirm.
+
+Results are written to a report CSV (one row per recipient). Running again
+with the same --report skips recipients already marked as sent, so an
+interrupted broadcast can be resumed without double-sending. Duplicate
+numbers within the CSV are only sent once.
+
+If the connection fails during a send, the phone may or may not have sent
+that message: it is recorded as unknown and the broadcast stops. A resume
+skips unknown recipients too; check the phone, then pass --retry-unknown to
+send to them again.
+
+--dry-run renders and previews the messages without connecting to the phone,
+so contact names are shown unresolved. It writes a report only when --report
+is given.
+
+Examples:
+  psh sms broadcast --csv testers.csv --template "Hi {{.Name}}, your slot is {{.Time}}"
+  psh sms broadcast --csv testers.csv --template-file pickup.tmpl --dry-run
+  psh sms broadcast --csv testers.csv --template-file pickup.tmpl --report pickup.csv --delay 5s`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		csvPath, _ := cmd.Flags().GetString("csv")
+		tmplText, _ := cmd.Flags().GetString("template")
+		tmplFile, _ := cmd.Flags().GetString("template-file")
+		toColumn, _ := cmd.Flags().GetString("to-column")
+		delay, _ := cmd.Flags().GetDuration("delay")
+		reportPath, _ := cmd.Flags().GetString("report")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		yes, _ := cmd.Flags().GetBool("yes")
+		retryUnknown, _ := cmd.Flags().GetBool("retry-unknown")
+
+		if csvPath == "" {
+			return fmt.Errorf("--csv is required")
+		}
+		if (tmplText == "") == (tmplFile == "") {
+			return fmt.Errorf("give exactly one of --template or --template-file")
+		}
+		if tmplFile != "" {
+			data, err := os.ReadFile(tmplFile)
+			if err != nil {
+				return err
+			}
+			tmplText = strings.TrimRight(string(data), "\n")
+		}
+		tmpl, err := template.New("sms").Option("missingkey=error").Parse(tmplText)
+		if err != nil {
+			return fmt.Errorf("parsing template: %w", err)
+		}
+		if reportPath == "" && !dryRun {
+			reportPath = "broadcast-" + time.Now().Format("20060102-150405") + ".csv"
+		}
+
+		header, rows, err := readBroadcastCSV(csvPath)
+		if err != nil {
+			return err
+		}
+		col, err := broadcastToColumn(header, toColumn)
+		if err != nil {
+			return err
+		}
+
+		var c *client.Client
+		var dev *client.Device
+		if !dryRun {
+			c, dev = mustConnect()
+			defer c.Close()
+		}
+
+		previous := reportStatuses(reportPath)
+		seen := map[string]bool{}
+		var recipients []broadcastRecipient
+		skipped := 0
+		for i, row := range rows {
+			fields := map[string]string{}
+			for j, h := range header {
+				if j < len(row) {
+					fields[h] = row[j]
+				} else {
+					fields[h] = ""
+				}
+			}
+			target := strings.TrimSpace(fields[header[col]])
+			if target == "" {
+				return fmt.Errorf("row %d: empty %s", i+1, header[col])
+			}
+			number, label := "", target+" (contact)"
+			// A dry run stays offline, so contact names are left unresolved
+			if !dryRun || looksLikeNumber(target) {
+				number, label, err = resolveRecipient(c, dev, target)
+				if err != nil {
+					return fmt.Errorf("row %d: %w", i+1, err)
+				}
+			}
+
+			key := numberKey(number)
+			if key == "" {
+				key = number
+			}
+			if key == "" {
+				key = strings.ToLower(target)
+			}
+			if seen[key] {
+				dim.Fprintf(os.Stderr, "row %d: %s is a duplicate, skipping\n", i+1, label)
+				skipped++
+				continue
+			}
+			seen[key] = true
+			switch previous[key] {
+			case "sent":
+				dim.Fprintf(os.Stderr, "row %d: %s already sent in %s, skipping\n", i+1, label, reportPath)
+				skipped++
+				continue
+			case "unknown":
+				if !retryUnknown {
+					dim.Fprintf(os.Stderr, "row %d: %s may have been sent (unknown in %s), skipping — see --retry-unknown\n", i+1, label, reportPath)
+					skipped++
+					continue
+				}
+			}
+
+			var msg strings.Builder
+			if err := tmpl.Execute(&msg, fields); err != nil {
+				return fmt.Errorf("row %d: %w", i+1, err)
+			}
+			if strings.TrimSpace(msg.String()) == "" {
+				return fmt.Errorf("row %d: template rendered an empty message", i+1)
+			}
+			recipients = append(recipients, broadcastRecipient{Row: i + 1, Number: number, Label: label, Message: msg.String()})
+		}
+
+		if len(recipients) == 0 {
+			dim.Println("Nothing to send")
+			return nil
+		}
+
+		// Preview
+		bold.Printf("%d message(s) to send", len(recipients))
+		if skipped > 0 {
+			dim.Printf(" (%d skipped)", skipped)
+		}
+		fmt.Println()
+		for i, r := range recipients {
+			if i == 3 {
+				dim.Printf("  ... and %d more\n", len(recipients)-3)
+				break
+			}
+			fmt.Printf("\n  → %s\n", cyan.Sprint(r.Label))
+			for _, line := range strings.Split(r.Message, "\n") {
+				fmt.Printf("    %s\n", line)
+			}
+		}
+		fmt.Println()
+
+		if !dryRun && !yes {
+			if !stdinIsTerminal() {
+				return fmt.Errorf("refusing to send without confirmation — pass --yes")
+			}
+			fmt.Printf("Send %d message(s), %s apart? [y/N] ", len(recipients), delay)
+			var confirm string
+			fmt.Scanln(&confirm)
+			if strings.ToLower(confirm) != "y" {
+				fmt.Println("Cancelled.")
+				return nil
+			}
+		}
+
+		var w *csv.Writer
+		if reportPath != "" {
+			report, err := openBroadcastReport(reportPath)
+			if err != nil {
+				return err
+			}
+			defer report.Close()
+			w = csv.NewWriter(report)
+		}
+
+		sent, failed, unknown := 0, 0, 0
+		for i, r := range recipients {
+			if i > 0 && !dryRun {
+				time.Sleep(delay)
+			}
+			status, errText := "dry-run", ""
+			if !dryRun {
+				res, err := c.Run(newCmd("sms", []string{"send", r.Number, r.Message}, nil))
+				switch {
+				case err != nil:
+					// The request may have reached the phone before the
+					// connection failed, so the SMS may well have gone out
+					status, errText = "unknown", err.Error()
+					unknown++
+				case !res.Ok:
+					status, errText = "failed", res.Error
+					failed++
+				default:
+					status = "sent"
+					sent++
+				}
+			}
+			if w != nil {
+				w.Write([]string{time.Now().Format(time.RFC3339), r.Number, r.Label, status, errText, r.Message})
+				w.Flush()
+			}
+
+			switch status {
+			case "sent":
+				green.Printf("  ✓ %s\n", r.Label)
+			case "failed":
+				red.Printf("  ✗ %s: %s\n", r.Label, errText)
+			case "unknown":
+				red.Printf("  ? %s: %s\n", r.Label, errText)
+			default:
+				dim.Printf("  - %s (dry run)\n", r.Label)
+			}
+			if status == "unknown" {
+				// A late reply would be read as the answer to the next send
+				red.Printf("Connection lost; stopping with %d message(s) not attempted\n", len(recipients)-i-1)
+				break
+			}
+		}
+		if w != nil {
+			if err := w.Error(); err != nil {
+				return fmt.Errorf("writing report: %w", err)
+			}
+		}
+
+		fmt.Println()
+		if dryRun {
+			fmt.Printf("Dry run: %d message(s) rendered, nothing sent.", len(recipients))
+			if reportPath != "" {
+				fmt.Printf(" Report: %s", reportPath)
+			}
+			fmt.Println()
+			return nil
+		}
+		fmt.Printf("Sent %d, failed %d, unknown %d. Report: %s\n", sent, failed, unknown, reportPath)
+		if failed > 0 || sent+failed+unknown < len(recipients) {
+			return fmt.Errorf("not every message was sent — re-run with --report %s to continue", reportPath)
+		}
+		if unknown > 0 {
+			return fmt.Errorf("%d message(s) may not have been sent — check the phone, then re-run with --report %s --retry-unknown if needed", unknown, reportPath)
+		}
+		return nil
+	},
+}
+
+func init() {
+	smsBroadcastCmd.Flags().String("csv", "", "CSV file of recipients, with a header row")
+	smsBroadcastCmd.Flags().String("template", "", "message template (Go text/template, e.g. \"Hi {{.Name}}\")")
+	smsBroadcastCmd.Flags().String("template-file", "", "read the message template from this file")
+	smsBroadcastCmd.Flags().String("to-column", "", "CSV column holding the number or contact name")
+	smsBroadcastCmd.Flags().Duration("delay", 3*time.Second, "wait between sends")
+	smsBroadcastCmd.Flags().String("report", "", "report CSV to append results to (default: broadcast-<time>.csv)")
+	smsBroadcastCmd.Flags().Bool("dry-run", false, "render and preview messages offline, without sending")
+	smsBroadcastCmd.Flags().BoolP("yes", "y", false, "skip the confirmation prompt")
+	smsBroadcastCmd.Flags().Bool("retry-unknown", false, "on resume, also send to recipients whose result is unknown in --report")
+
+	smsCmd.AddCommand(smsBroadcastCmd)
+}
+
+func readBroadcastCSV(path string) ([]string, [][]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(bufio.NewReader(f))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	header, err := r.Read()
+	if err == io.EOF {
+		return nil, nil, fmt.Errorf("%s is empty", path)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	for i, h := range header {
+		header[i] = strings.TrimSpace(strings.TrimPrefix(h, "\ufeff"))
+	}
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return header, rows, nil
+}
+
+// broadcastToColumn finds the recipient column: the named one, or the first
+// that looks like a phone number column.
+func broadcastToColumn(header []string, name string) (int, error) {
+	for i, h := range header {
+		if name != "" && strings.EqualFold(h, name) {
+			return i, nil
+		}
+	}
+	if name != "" {
+		return 0, fmt.Errorf("no column %q in CSV (have: %s)", name, strings.Join(header, ", "))
+	}
+	for _, want := range []string{"number", "phone", "mobile", "to"} {
+		for i, h := range header {
+			if strings.EqualFold(h, want) {
+				return i, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("no number column found (have: %s) — use --to-column", strings.Join(header, ", "))
+}
+
+// reportStatuses returns the latest status of each number in an existing
+// report, keyed by numberKey. Once sent, a number stays sent.
+func reportStatuses(path string) map[string]string {
+	statuses := map[string]string{}
+	f, err := os.Open(path)
+	if err != nil {
+		return statuses
+	}
+	defer f.Close()
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	rows, _ := r.ReadAll()
+	for _, row := range rows {
+		if len(row) < 4 {
+			continue
+		}
+		key := numberKey(row[1])
+		if key == "" {
+			key = row[1]
+		}
+		if statuses[key] != "sent" {
+			statuses[key] = row[3]
+		}
+	}
+	return statuses
+}
+
+// openBroadcastReport opens the report for appending, writing a header when
+// the file is new.
+func openBroadcastReport(path string) (*os.File, error) {
+	_, statErr := os.Stat(path)
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if os.IsNotExist(statErr) {
+		w := csv.NewWriter(f)
+		w.Write([]string{"time", "number", "recipient", "status", "error", "message"})
+		w.Flush()
+	}
+	return f, nil
+}
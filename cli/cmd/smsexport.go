@@ -0,0 +1,518 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/phonessh/psh/client"
+	"github.com/spf13/cobra"
+)
+
+// smsPageSize is how many messages are fetched per 'sms list' call while exporting.
+const smsPageSize = 500
+
+// smsImportBatch is how many messages are sent per 'sms import' call.
+const smsImportBatch = 200
+
+// smsMessage is the exported form of one SMS.
+type smsMessage struct {
+	ID       int64  `json:"id"`
+	ThreadID int64  `json:"thread_id"`
+	Address  string `json:"address"`
+	Body     string `json:"body"`
+	Type     string `json:"type"` // inbox, sent, draft, outbox, failed, queued
+	Read     bool   `json:"read"`
+	Date     int64  `json:"date"` // epoch millis
+}
+
+func (m smsMessage) Time() time.Time { return time.UnixMilli(m.Date) }
+
+var smsExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export SMS messages to JSON, CSV, XML or mbox",
+	Long: `Export every SMS on the phone (or a subset) to a file.
+
+Formats:
+  json   array of messages (round-trips with 'psh sms import')
+  csv    one row per message
+  xml    "SMS Backup & Restore" compatible <smses> file
+  mbox   one email per message, for mail clients and archiving tools
+
+Examples:
+  psh sms export --format xml -o sms-backup.xml
+  psh sms export --format csv --since 30d > last-month.csv
+  psh sms export --format mbox --thread 42 -o thread42.mbox`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+		output, _ := cmd.Flags().GetString("output")
+		format = strings.ToLower(format)
+		switch format {
+		case "json", "csv", "xml", "mbox":
+		default:
+			return fmt.Errorf("unknown format %q — use json, csv, xml or mbox", format)
+		}
+
+		flags := map[string]string{}
+		if since, _ := cmd.Flags().GetString("since"); since != "" {
+			t, err := parseSince(since)
+			if err != nil {
+				return err
+			}
+			flags["since"] = strconv.FormatInt(t.UnixMilli(), 10)
+		}
+		if thread, _ := cmd.Flags().GetInt64("thread"); thread > 0 {
+			flags["thread"] = strconv.FormatInt(thread, 10)
+		}
+
+		c, _ := mustConnect()
+		defer c.Close()
+
+		msgs, err := fetchAllSMS(c, flags)
+		if err != nil {
+			return err
+		}
+
+		var w io.Writer = os.Stdout
+		if output != "" && output != "-" {
+			f, err := os.Create(output)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			w = f
+		}
+		bw := bufio.NewWriter(w)
+
+		switch format {
+		case "json":
+			err = writeSMSJSON(bw, msgs)
+		case "csv":
+			err = writeSMSCSV(bw, msgs)
+		case "xml":
+			err = writeSMSXML(bw, msgs)
+		case "mbox":
+			err = writeSMSMbox(bw, msgs)
+		}
+		if err != nil {
+			return err
+		}
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+
+		if output != "" && output != "-" {
+			green.Printf("Exported %d message(s) to %s\n", len(msgs), output)
+		}
+		return nil
+	},
+}
+
+var smsImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Restore SMS messages from an export or SMS Backup & Restore file",
+	Long: `Restore messages to the phone from a json, csv or xml file produced by
+'psh sms export' (xml files from SMS Backup & Restore also work).
+Messages already on the phone are skipped.
+
+Android only lets the default SMS app write messages: temporarily make
+PhoneSSH the default SMS app while importing.
+
+Examples:
+  psh sms import sms-backup.xml
+  psh sms import export.json --dry-run`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		if format == "" {
+			format = strings.TrimPrefix(strings.ToLower(filepath.Ext(args[0])), ".")
+		}
+
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		var msgs []smsMessage
+		switch format {
+		case "json":
+			err = json.NewDecoder(f).Decode(&msgs)
+		case "csv":
+			msgs, err = readSMSCSV(f)
+		case "xml":
+			msgs, err = readSMSXML(f)
+		case "mbox":
+			return fmt.Errorf("mbox exports are for archiving and can't be imported — use json, csv or xml")
+		default:
+			return fmt.Errorf("can't tell the format of %s — pass --format json|csv|xml", args[0])
+		}
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", args[0], err)
+		}
+
+		fmt.Printf("%d message(s) in %s\n", len(msgs), args[0])
+		if dryRun || len(msgs) == 0 {
+			return nil
+		}
+
+		c, _ := mustConnect()
+		defer c.Close()
+
+		inserted, skipped := 0, 0
+		for start := 0; start < len(msgs); start += smsImportBatch {
+			end := min(start+smsImportBatch, len(msgs))
+			payload, err := json.Marshal(msgs[start:end])
+			if err != nil {
+				return err
+			}
+			msg := newCmd("sms", []string{"import"}, nil)
+			msg.Payload = base64.StdEncoding.EncodeToString(payload)
+			data, err := c.RunRaw(msg)
+			if err != nil {
+				return err
+			}
+			ins, _ := data["inserted"].(float64)
+			sk, _ := data["skipped"].(float64)
+			inserted += int(ins)
+			skipped += int(sk)
+			dim.Printf("  %d/%d\n", end, len(msgs))
+		}
+		green.Printf("Restored %d message(s), skipped %d already present\n", inserted, skipped)
+		return nil
+	},
+}
+
+func init() {
+	smsExportCmd.Flags().String("format", "json", "output format: json, csv, xml, mbox")
+	smsExportCmd.Flags().StringP("output", "o", "", "write to this file instead of stdout")
+	smsExportCmd.Flags().String("since", "", "only export messages newer than this (e.g. 30d, 2006-01-02)")
+	smsExportCmd.Flags().Int64("thread", 0, "only export this conversation thread")
+
+	smsImportCmd.Flags().String("format", "", "input format: json, csv, xml (default: from file extension)")
+	smsImportCmd.Flags().Bool("dry-run", false, "parse the file and report, without writing to the phone")
+
+	smsCmd.AddCommand(smsExportCmd)
+	smsCmd.AddCommand(smsImportCmd)
+}
+
+// fetchAllSMS pages through 'sms list' until every matching message is read.
+func fetchAllSMS(c *client.Client, filter map[string]string) ([]smsMessage, error) {
+	var out []smsMessage
+	seen := map[int64]bool{}
+	for offset := 0; ; offset += smsPageSize {
+		flags := map[string]string{
+			"limit":  strconv.Itoa(smsPageSize),
+			"offset": strconv.Itoa(offset),
+		}
+		for k, v := range filter {
+			flags[k] = v
+		}
+		data, err := c.RunRaw(newCmd("sms", []string{"list"}, flags))
+		if err != nil {
+			return nil, err
+		}
+		page := smsFromData(data)
+		for _, m := range page {
+			// New messages arriving mid-export shift the offsets; skip repeats
+			if seen[m.ID] {
+				continue
+			}
+			seen[m.ID] = true
+			out = append(out, m)
+		}
+		if len(page) < smsPageSize {
+			return out, nil
+		}
+		dim.Fprintf(os.Stderr, "  fetched %d...\n", len(out))
+	}
+}
+
+// smsFromData converts an 'sms list' response into messages.
+func smsFromData(data map[string]interface{}) []smsMessage {
+	items, _ := data["messages"].([]interface{})
+	out := make([]smsMessage, 0, len(items))
+	for _, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		id, _ := m["id"].(float64)
+		thread, _ := m["thread_id"].(float64)
+		ts, _ := m["time"].(float64)
+		read, _ := m["read"].(bool)
+		body, _ := m["body"].(string) // untrimmed, unlike str()
+		out = append(out, smsMessage{
+			ID:       int64(id),
+			ThreadID: int64(thread),
+			Address:  str(m["from"]),
+			Body:     body,
+			Type:     str(m["type"]),
+			Read:     read,
+			Date:     int64(ts),
+		})
+	}
+	return out
+}
+
+// ── JSON / CSV ───────────────────────────────────────────────────────────────
+
+func writeSMSJSON(w io.Writer, msgs []smsMessage) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if msgs == nil {
+		msgs = []smsMessage{}
+	}
+	return enc.Encode(msgs)
+}
+
+var smsCSVHeader = []string{"id", "thread_id", "address", "type", "date", "date_ms", "read", "body"}
+
+func writeSMSCSV(w io.Writer, msgs []smsMessage) error {
+	cw := csv.NewWriter(w)
+	cw.Write(smsCSVHeader)
+	for _, m := range msgs {
+		cw.Write([]string{
+			strconv.FormatInt(m.ID, 10),
+			strconv.FormatInt(m.ThreadID, 10),
+			m.Address,
+			m.Type,
+			m.Time().Format(time.RFC3339),
+			strconv.FormatInt(m.Date, 10),
+			strconv.FormatBool(m.Read),
+			m.Body,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func readSMSCSV(r io.Reader) ([]smsMessage, error) {
+	rows, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	col := map[string]int{}
+	for i, name := range rows[0] {
+		col[name] = i
+	}
+	for _, name := range []string{"address", "type", "date_ms", "body"} {
+		if _, ok := col[name]; !ok {
+			return nil, fmt.Errorf("missing column %q", name)
+		}
+	}
+
+	var out []smsMessage
+	for i, row := range rows[1:] {
+		date, err := strconv.ParseInt(row[col["date_ms"]], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: invalid date_ms", i+2)
+		}
+		m := smsMessage{
+			Address: row[col["address"]],
+			Type:    row[col["type"]],
+			Body:    row[col["body"]],
+			Date:    date,
+			Read:    true,
+		}
+		if idx, ok := col["read"]; ok {
+			m.Read, _ = strconv.ParseBool(row[idx])
+		}
+		out = append(out, m)
+	}
+	return out, nil
+}
+
+// ── SMS Backup & Restore XML ─────────────────────────────────────────────────
+
+type sbrSmses struct {
+	XMLName    xml.Name `xml:"smses"`
+	Count      int      `xml:"count,attr"`
+	BackupSet  string   `xml:"backup_set,attr,omitempty"`
+	BackupDate int64    `xml:"backup_date,attr"`
+	Type       string   `xml:"type,attr,omitempty"`
+	SMS        []sbrSMS `xml:"sms"`
+}
+
+type sbrSMS struct {
+	Protocol      string `xml:"protocol,attr"`
+	Address       string `xml:"address,attr"`
+	Date          int64  `xml:"date,attr"`
+	Type          int    `xml:"type,attr"`
+	Subject       string `xml:"subject,attr"`
+	Body          string `xml:"body,attr"`
+	Toa           string `xml:"toa,attr"`
+	ScToa         string `xml:"sc_toa,attr"`
+	ServiceCenter string `xml:"service_center,attr"`
+	Read          int    `xml:"read,attr"`
+	Status        int    `xml:"status,attr"`
+	Locked        int    `xml:"locked,attr"`
+	DateSent      int64  `xml:"date_sent,attr"`
+	SubID         int    `xml:"sub_id,attr"`
+	ReadableDate  string `xml:"readable_date,attr"`
+	ContactName   string `xml:"contact_name,attr"`
+}
+
+var sbrTypes = map[string]int{"inbox": 1, "sent": 2, "draft": 3, "outbox": 4, "failed": 5, "queued": 6}
+
+func writeSMSXML(w io.Writer, msgs []smsMessage) error {
+	doc := sbrSmses{
+		Count:      len(msgs),
+		BackupSet:  fmt.Sprintf("psh-%d", time.Now().UnixNano()),
+		BackupDate: time.Now().UnixMilli(),
+		Type:       "full",
+	}
+	for _, m := range msgs {
+		typ, ok := sbrTypes[m.Type]
+		if !ok {
+			typ = 1
+		}
+		read := 0
+		if m.Read {
+			read = 1
+		}
+		doc.SMS = append(doc.SMS, sbrSMS{
+			Protocol:      "0",
+			Address:       m.Address,
+			Date:          m.Date,
+			Type:          typ,
+			Subject:       "null",
+			Body:          m.Body,
+			Toa:           "null",
+			ScToa:         "null",
+			ServiceCenter: "null",
+			Read:          read,
+			Status:        -1,
+			SubID:         -1,
+			ReadableDate:  m.Time().Format("Jan 2, 2006 3:04:05 PM"),
+			ContactName:   "(Unknown)",
+		})
+	}
+
+	io.WriteString(w, "<?xml version='1.0' encoding='UTF-8' standalone='yes' ?>\n")
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func readSMSXML(r io.Reader) ([]smsMessage, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var doc sbrSmses
+	if err := xml.Unmarshal(joinSurrogateRefs(raw), &doc); err != nil {
+		return nil, err
+	}
+	names := map[int]string{}
+	for name, n := range sbrTypes {
+		names[n] = name
+	}
+	out := make([]smsMessage, 0, len(doc.SMS))
+	for _, s := range doc.SMS {
+		typ, ok := names[s.Type]
+		if !ok {
+			typ = "inbox"
+		}
+		out = append(out, smsMessage{
+			Address: s.Address,
+			Body:    s.Body,
+			Type:    typ,
+			Read:    s.Read == 1,
+			Date:    s.Date,
+		})
+	}
+	return out, nil
+}
+
+// surrogateRefRe matches a character reference pair for a UTF-16 surrogate
+// pair, e.g. &#55357;&#56832; or &#xD83D;&#xDE00;.
+var surrogateRefRe = regexp.MustCompile(`&#(\d{5}|[xX][0-9a-fA-F]{4});&#(\d{5}|[xX][0-9a-fA-F]{4});`)
+
+// joinSurrogateRefs rewrites surrogate pair references, which SMS Backup &
+// Restore writes for emoji and encoding/xml decodes to U+FFFD each, into one
+// reference for the combined code point.
+func joinSurrogateRefs(b []byte) []byte {
+	ref := func(s []byte) rune {
+		var n int64
+		if s[0] == 'x' || s[0] == 'X' {
+			n, _ = strconv.ParseInt(string(s[1:]), 16, 32)
+		} else {
+			n, _ = strconv.ParseInt(string(s), 10, 32)
+		}
+		return rune(n)
+	}
+	return surrogateRefRe.ReplaceAllFunc(b, func(m []byte) []byte {
+		sub := surrogateRefRe.FindSubmatch(m)
+		hi, lo := ref(sub[1]), ref(sub[2])
+		if hi < 0xD800 || hi > 0xDBFF || lo < 0xDC00 || lo > 0xDFFF {
+			return m
+		}
+		return []byte(fmt.Sprintf("&#%d;", utf16.DecodeRune(hi, lo)))
+	})
+}
+
+// ── mbox ─────────────────────────────────────────────────────────────────────
+
+func writeSMSMbox(w io.Writer, msgs []smsMessage) error {
+	for _, m := range msgs {
+		from, to := m.Address, "me"
+		if m.Type == "sent" {
+			from, to = "me", m.Address
+		}
+		t := m.Time()
+		fmt.Fprintf(w, "From %s %s\n", mboxAddr(from), t.UTC().Format("Mon Jan _2 15:04:05 2006"))
+		fmt.Fprintf(w, "From: %s\n", from)
+		fmt.Fprintf(w, "To: %s\n", to)
+		fmt.Fprintf(w, "Date: %s\n", t.Format(time.RFC1123Z))
+		if m.Type == "sent" {
+			fmt.Fprintf(w, "Subject: SMS to %s\n", m.Address)
+		} else {
+			fmt.Fprintf(w, "Subject: SMS from %s\n", m.Address)
+		}
+		fmt.Fprintf(w, "Message-ID: <sms-%d-%d@psh>\n", m.ID, m.Date)
+		fmt.Fprintf(w, "X-SMS-Type: %s\n", m.Type)
+		fmt.Fprintf(w, "X-SMS-Thread: %d\n", m.ThreadID)
+		fmt.Fprintf(w, "MIME-Version: 1.0\n")
+		fmt.Fprintf(w, "Content-Type: text/plain; charset=utf-8\n\n")
+		for _, line := range strings.Split(m.Body, "\n") {
+			// mboxrd quoting
+			if strings.HasPrefix(strings.TrimLeft(line, ">"), "From ") {
+				line = ">" + line
+			}
+			fmt.Fprintln(w, line)
+		}
+		if _, err := fmt.Fprintln(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func mboxAddr(s string) string {
+	s = strings.ReplaceAll(s, " ", "")
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/phonessh/psh/client"
+	"github.com/spf13/cobra"
+)
+
+// mmsMaxAttachBytes is a conservative total attachment size; most carriers
+// reject MMS over 1 MB (the phone checks its own carrier limit too).
+const mmsMaxAttachBytes = 1 << 20
+
+var smsPullAttachmentCmd = &cobra.Command{
+	Use:   "pull-attachment <part-id> [local-path]",
+	Short: "Download an MMS attachment",
+	Long: `Download an MMS attachment (image, video, vCard, ...) to this computer.
+
+Part IDs are shown next to each attachment in 'psh sms list' and
+'psh sms thread'.
+
+Examples:
+  psh sms pull-attachment 1234
+  psh sms pull-attachment 1234 ~/Pictures/`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if _, err := strconv.ParseInt(args[0], 10, 64); err != nil {
+			return fmt.Errorf("invalid part id %q", args[0])
+		}
+		c, _ := mustConnect()
+		defer c.Close()
+
+		data, err := c.RunRaw(newCmd("sms", []string{"attachment", args[0]}, nil))
+		if err != nil {
+			return err
+		}
+		content, _ := data["content"].(string)
+		fileBytes, err := base64.StdEncoding.DecodeString(content)
+		if err != nil {
+			return fmt.Errorf("decoding attachment: %w", err)
+		}
+
+		name := filepath.Base(str(data["filename"]))
+		if filepath.Ext(name) == "" {
+			if exts, _ := mime.ExtensionsByType(str(data["content_type"])); len(exts) > 0 {
+				name += exts[0]
+			}
+		}
+		localPath := name
+		if len(args) == 2 {
+			localPath = args[1]
+			if info, err := os.Stat(localPath); (err == nil && info.IsDir()) || strings.HasSuffix(localPath, string(os.PathSeparator)) {
+				os.MkdirAll(localPath, 0755)
+				localPath = filepath.Join(localPath, name)
+			}
+		}
+
+		if err := os.WriteFile(localPath, fileBytes, 0644); err != nil {
+			return fmt.Errorf("writing %s: %w", localPath, err)
+		}
+		green.Printf("Saved: %s (%s, %s)\n", localPath, str(data["content_type"]), formatSize(int64(len(fileBytes))))
+		return nil
+	},
+}
+
+func init() {
+	smsCmd.AddCommand(smsPullAttachmentCmd)
+}
+
+type mmsAttachment struct {
+	Name string `json:"name"`
+	Mime string `json:"mime"`
+	Data string `json:"data"` // base64
+}
+
+// sendMMS sends a group and/or attachment message through the daemon.
+func sendMMS(c *client.Client, numbers, labels []string, message string, attachPaths []string) error {
+	var attachments []mmsAttachment
+	total := 0
+	for _, p := range attachPaths {
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", p, err)
+		}
+		total += len(b)
+		mt := mime.TypeByExtension(strings.ToLower(filepath.Ext(p)))
+		if mt == "" {
+			mt = http.DetectContentType(b)
+		}
+		if i := strings.Index(mt, ";"); i >= 0 {
+			mt = mt[:i]
+		}
+		attachments = append(attachments, mmsAttachment{Name: filepath.Base(p), Mime: mt, Data: base64.StdEncoding.EncodeToString(b)})
+	}
+	if total > mmsMaxAttachBytes {
+		return fmt.Errorf("attachments total %s; MMS is usually limited to %s — resize first", formatSize(int64(total)), formatSize(mmsMaxAttachBytes))
+	}
+
+	msg := newCmd("sms", []string{"send-mms", message}, map[string]string{"to": strings.Join(numbers, ",")})
+	if len(attachments) > 0 {
+		payload, err := json.Marshal(attachments)
+		if err != nil {
+			return err
+		}
+		msg.Payload = base64.StdEncoding.EncodeToString(payload)
+	}
+
+	fmt.Printf("Sending MMS to %s", strings.Join(labels, ", "))
+	if len(attachments) > 0 {
+		fmt.Printf(" with %d attachment(s)", len(attachments))
+	}
+	fmt.Println()
+	data, err := c.RunRaw(msg)
+	if err != nil {
+		return err
+	}
+	if confirmed, ok := data["confirmed"].(bool); ok && !confirmed {
+		dim.Println("Handed to the phone's MMS service; no result yet (check the phone)")
+		return nil
+	}
+	green.Println("Sent")
+	return nil
+}
+
+// mmsText renders an MMS row's body plus one line per attachment. With
+// names, incoming group messages are prefixed with the sender.
+func mmsText(msg map[string]interface{}, names contactNames) string {
+	var lines []string
+	if names != nil && str(msg["type"]) != "sent" {
+		if to, _ := msg["to"].([]interface{}); len(to) > 1 {
+			lines = append(lines, names.label(str(msg["from"]))+":")
+		}
+	}
+	if body := str(msg["body"]); body != "" {
+		lines = append(lines, body)
+	}
+	atts, _ := msg["attachments"].([]interface{})
+	for _, a := range atts {
+		att, ok := a.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		partID, _ := att["part_id"].(float64)
+		lines = append(lines, fmt.Sprintf("[%s %s — part %d]", str(att["content_type"]), str(att["filename"]), int64(partID)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// mmsRecipients describes the other party of an MMS row for one-line listings.
+func mmsRecipients(msg map[string]interface{}, names contactNames) string {
+	if str(msg["type"]) != "sent" {
+		return names.label(str(msg["from"]))
+	}
+	to, _ := msg["to"].([]interface{})
+	var parts []string
+	for _, t := range to {
+		parts = append(parts, names.label(str(t)))
+	}
+	if len(parts) > 2 {
+		return fmt.Sprintf("%s +%d", strings.Join(parts[:2], ", "), len(parts)-2)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,308 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/phonessh/psh/client"
+	"github.com/phonessh/psh/schedule"
+	"github.com/spf13/cobra"
+)
+
+// Scheduled message IDs are shown as L<n> (kept on this computer) or P<n>
+// (kept on the phone) so 'cancel' knows where to look.
+
+var smsScheduledCmd = &cobra.Command{
+	Use:   "scheduled",
+	Short: "List or cancel scheduled SMS",
+	Long: `Show SMS scheduled with 'psh sms send --at/--in'.
+
+IDs starting with L are kept on this computer and sent by 'psh agent';
+IDs starting with P are kept on the phone.
+
+Examples:
+  psh sms scheduled list
+  psh sms scheduled list --all
+  psh sms scheduled cancel L3`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return smsScheduledListCmd.RunE(cmd, args)
+	},
+}
+
+var smsScheduledListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List scheduled SMS",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		all, _ := cmd.Flags().GetBool("all")
+
+		// Messages queued on this computer are listed even when the phone
+		// is unreachable.
+		dev, err := selectedDevice()
+		if err != nil {
+			return err
+		}
+
+		type row struct {
+			id, to, status, body string
+			at                   time.Time
+		}
+		var rows []row
+
+		local, err := schedule.Load()
+		if err != nil {
+			return err
+		}
+		for _, m := range local {
+			if m.Device != dev.Name {
+				continue
+			}
+			to := m.To
+			if m.Label != "" {
+				to = m.Label
+			}
+			status := m.Status
+			if m.Error != "" {
+				status += ": " + m.Error
+			}
+			rows = append(rows, row{fmt.Sprintf("L%d", m.ID), to, status, m.Body, m.At})
+		}
+
+		c, _, err := getClient()
+		var data map[string]interface{}
+		if err == nil {
+			defer c.Close()
+			data, err = c.RunRaw(newCmd("sms", []string{"scheduled"}, nil))
+		}
+		if err != nil {
+			red.Fprintf(os.Stderr, "Phone-side schedule unavailable: %v\n", err)
+		} else {
+			names := loadContactNames(c, dev)
+			entries, _ := data["scheduled"].([]interface{})
+			for _, e := range entries {
+				m, ok := e.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				at, _ := m["at"].(float64)
+				id, _ := m["id"].(float64)
+				status := str(m["status"])
+				if m["error"] != nil {
+					status += ": " + str(m["error"])
+				}
+				rows = append(rows, row{fmt.Sprintf("P%d", int64(id)), names.label(str(m["to"])), status, str(m["body"]), time.UnixMilli(int64(at))})
+			}
+		}
+
+		if !all {
+			pending := rows[:0]
+			for _, r := range rows {
+				if r.status == schedule.Pending || r.status == schedule.Sending {
+					pending = append(pending, r)
+				}
+			}
+			rows = pending
+		}
+		if len(rows) == 0 {
+			dim.Println("No scheduled messages")
+			return nil
+		}
+		sort.Slice(rows, func(i, j int) bool { return rows[i].at.Before(rows[j].at) })
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "ID\tWHEN\tTO\tSTATUS\tMESSAGE\n")
+		for _, r := range rows {
+			body := strings.ReplaceAll(r.body, "\n", " ")
+			if len(body) > 50 {
+				body = body[:47] + "..."
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", r.id, r.at.Format("Jan 02 15:04"), r.to, r.status, body)
+		}
+		w.Flush()
+		return nil
+	},
+}
+
+var smsScheduledCancelCmd = &cobra.Command{
+	Use:   "cancel <id>",
+	Short: "Cancel a scheduled SMS",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id := strings.ToUpper(args[0])
+		if len(id) < 2 || (id[0] != 'L' && id[0] != 'P') {
+			return fmt.Errorf("invalid id %q — use the L… or P… id from 'psh sms scheduled list'", args[0])
+		}
+		n, err := strconv.Atoi(id[1:])
+		if err != nil {
+			return fmt.Errorf("invalid id %q", args[0])
+		}
+
+		if id[0] == 'L' {
+			if err := schedule.Cancel(n); err != nil {
+				return err
+			}
+		} else {
+			c, _ := mustConnect()
+			defer c.Close()
+			if _, err := c.RunRaw(newCmd("sms", []string{"unschedule", strconv.Itoa(n)}, nil)); err != nil {
+				return err
+			}
+		}
+		green.Printf("Cancelled %s\n", id)
+		return nil
+	},
+}
+
+func init() {
+	smsScheduledListCmd.Flags().Bool("all", false, "include sent, failed and cancelled messages")
+	smsScheduledCmd.Flags().Bool("all", false, "include sent, failed and cancelled messages")
+
+	smsScheduledCmd.AddCommand(smsScheduledListCmd)
+	smsScheduledCmd.AddCommand(smsScheduledCancelCmd)
+	smsCmd.AddCommand(smsScheduledCmd)
+}
+
+// sendAt returns the time requested by 'sms send --at/--in', or zero to send now.
+func sendAt(cmd *cobra.Command) (time.Time, error) {
+	atStr, _ := cmd.Flags().GetString("at")
+	inStr, _ := cmd.Flags().GetString("in")
+	switch {
+	case atStr != "" && inStr != "":
+		return time.Time{}, fmt.Errorf("use either --at or --in, not both")
+	case inStr != "":
+		d, err := parseDuration(inStr)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.Now().Add(d), nil
+	case atStr != "":
+		at, err := parseAt(atStr)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if !at.After(time.Now()) {
+			return time.Time{}, fmt.Errorf("--at %s is in the past", at.Format("2006-01-02 15:04"))
+		}
+		return at, nil
+	}
+	return time.Time{}, nil
+}
+
+// parseAt accepts a local date and time, RFC 3339, or a bare clock time,
+// which means the next time that clock time comes round.
+func parseAt(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range []string{"2006-01-02 15:04", "2006-01-02 15:04:05", "2006-01-02T15:04"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if clock, err := time.ParseInLocation("15:04", s, time.Local); err == nil {
+		now := time.Now()
+		t := time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), 0, 0, time.Local)
+		if !t.After(now) {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --at %q — use \"2006-01-02 15:04\", RFC 3339, or \"15:04\"", s)
+}
+
+// scheduleSMS queues a message on this computer or on the phone.
+func scheduleSMS(c *client.Client, dev *client.Device, number, label, message string, at time.Time, onPhone bool) error {
+	when := at.Format("Mon Jan 02 15:04")
+	if onPhone {
+		data, err := c.RunRaw(newCmd("sms", []string{"schedule", number, message},
+			map[string]string{"at": strconv.FormatInt(at.UnixMilli(), 10)}))
+		if err != nil {
+			return err
+		}
+		id, _ := data["id"].(float64)
+		green.Printf("Scheduled P%d on the phone: %s at %s\n", int64(id), label, when)
+		return nil
+	}
+
+	m := &schedule.Message{Device: dev.Name, To: number, Body: message, At: at}
+	if label != number {
+		m.Label = label
+	}
+	if err := schedule.Add(m); err != nil {
+		return err
+	}
+	green.Printf("Scheduled L%d: %s at %s\n", m.ID, label, when)
+	dim.Println("Sent by 'psh agent' — keep it running, or use --on-phone to let the phone send it.")
+	return nil
+}
+
+// sendDueSMS sends this device's pending local messages whose time has come.
+// Messages more than maxLate overdue (e.g. the computer was asleep) are
+// marked failed rather than sent late.
+func sendDueSMS(c *client.Client, dev *client.Device, maxLate time.Duration, dryRun bool) error {
+	// Only rewrite the file when something is due, so the agent doesn't race
+	// 'sms send --at' on every poll.
+	msgs, err := schedule.Load()
+	if err != nil {
+		return err
+	}
+	due := false
+	for _, m := range msgs {
+		if m.Status == schedule.Pending && m.Device == dev.Name && !m.At.After(time.Now()) {
+			due = true
+		}
+	}
+	if !due {
+		return nil
+	}
+
+	// Claim the due messages under the lock, then send with no lock held: a
+	// slow phone must not keep 'sms send --at' or another agent waiting.
+	var claimed []schedule.Message
+	err = schedule.Update(func(msgs []*schedule.Message) ([]*schedule.Message, error) {
+		now := time.Now()
+		for _, m := range msgs {
+			if m.Status != schedule.Pending || m.Device != dev.Name || m.At.After(now) {
+				continue
+			}
+			if maxLate > 0 && now.Sub(m.At) > maxLate {
+				m.Status, m.Error, m.Done = schedule.Failed, fmt.Sprintf("missed: %s late", now.Sub(m.At).Round(time.Minute)), now
+				red.Printf("%s  scheduled SMS L%d to %s %s\n", now.Format("15:04:05"), m.ID, m.Display(), m.Error)
+				continue
+			}
+			if !dryRun {
+				m.Status = schedule.Sending
+			}
+			claimed = append(claimed, *m)
+		}
+		return msgs, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, m := range claimed {
+		ts := time.Now().Format("15:04:05")
+		if dryRun {
+			dim.Printf("%s  [dry-run] would send scheduled SMS L%d to %s\n", ts, m.ID, m.Display())
+			continue
+		}
+		status, errText := schedule.Sent, ""
+		if _, err := c.RunRaw(newCmd("sms", []string{"send", m.To, m.Body}, nil)); err != nil {
+			status, errText = schedule.Failed, err.Error()
+			red.Printf("%s  scheduled SMS L%d to %s failed: %v\n", ts, m.ID, m.Display(), err)
+		} else {
+			green.Printf("%s  sent scheduled SMS L%d to %s\n", ts, m.ID, m.Display())
+		}
+		if err := schedule.Finish(m.ID, status, errText); err != nil {
+			return err
+		}
+	}
+	return nil
+}
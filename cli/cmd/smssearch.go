@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var highlight = color.New(color.FgYellow, color.Bold)
+
+var smsSearchCmd = &cobra.Command{
+	Use:   "search <query>",
+	Short: "Search message bodies across all threads",
+	Long: `Search the body of every SMS on the phone. The search runs on the phone,
+so only matching messages are transferred.
+
+Plain queries match case-insensitively as a substring. With --regex the
+query is a regular expression (case-sensitive unless it starts with (?i)).
+
+-C shows that many messages before and after each match in the same
+conversation, like grep -C.
+
+Examples:
+  psh sms search invoice
+  psh sms search --regex '\b[A-Z]{2}\d{6}\b' --since 30d
+  psh sms search pickup --from Alice -C 2`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		query := strings.Join(args, " ")
+		useRegex, _ := cmd.Flags().GetBool("regex")
+		since, _ := cmd.Flags().GetString("since")
+		from, _ := cmd.Flags().GetString("from")
+		limit, _ := cmd.Flags().GetInt("limit")
+		contextN, _ := cmd.Flags().GetInt("context")
+
+		// Compile locally too: it validates the pattern before the round trip
+		// and drives highlighting.
+		var re *regexp.Regexp
+		var err error
+		if useRegex {
+			re, err = regexp.Compile(query)
+		} else {
+			re, err = regexp.Compile("(?i)" + regexp.QuoteMeta(query))
+		}
+		if err != nil {
+			return fmt.Errorf("invalid regex: %w", err)
+		}
+
+		c, dev := mustConnect()
+		defer c.Close()
+
+		flags := map[string]string{"limit": strconv.Itoa(limit)}
+		if useRegex {
+			flags["regex"] = "true"
+		}
+		if contextN > 0 {
+			flags["context"] = strconv.Itoa(contextN)
+		}
+		if since != "" {
+			t, err := parseSince(since)
+			if err != nil {
+				return err
+			}
+			flags["since"] = strconv.FormatInt(t.UnixMilli(), 10)
+		}
+		if from != "" {
+			if !looksLikeNumber(from) {
+				number, _, err := resolveRecipient(c, dev, from)
+				if err != nil {
+					return err
+				}
+				from = number
+			}
+			if key := numberKey(from); key != "" {
+				from = key
+			}
+			flags["from"] = from
+		}
+
+		data, err := c.RunRaw(newCmd("sms", []string{"search", query}, flags))
+		if err != nil {
+			return err
+		}
+		msgs, _ := data["messages"].([]interface{})
+		if len(msgs) == 0 {
+			dim.Println("No matches")
+			return nil
+		}
+
+		names := loadContactNames(c, dev)
+		for i, m := range msgs {
+			msg, ok := m.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if i > 0 && contextN > 0 {
+				dim.Println("--")
+			}
+			for _, b := range searchContext(msg["before"]) {
+				printSearchLine(b, names, nil)
+			}
+			printSearchLine(msg, names, re)
+			for _, a := range searchContext(msg["after"]) {
+				printSearchLine(a, names, nil)
+			}
+		}
+
+		fmt.Println()
+		fmt.Printf("%d match(es)", len(msgs))
+		if len(msgs) >= limit {
+			dim.Printf(" (limit reached — use --limit for more)")
+		}
+		fmt.Println()
+		return nil
+	},
+}
+
+func init() {
+	smsSearchCmd.Flags().Bool("regex", false, "treat the query as a regular expression")
+	smsSearchCmd.Flags().String("since", "", "only search messages newer than this (e.g. 30d, 2006-01-02)")
+	smsSearchCmd.Flags().String("from", "", "only search messages with this number or contact")
+	smsSearchCmd.Flags().Int("limit", 50, "max matches to show")
+	smsSearchCmd.Flags().IntP("context", "C", 0, "show this many messages around each match")
+
+	smsCmd.AddCommand(smsSearchCmd)
+}
+
+func searchContext(v interface{}) []map[string]interface{} {
+	list, _ := v.([]interface{})
+	out := make([]map[string]interface{}, 0, len(list))
+	for _, item := range list {
+		if m, ok := item.(map[string]interface{}); ok {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// printSearchLine prints one message on a single line. Matches of re are
+// highlighted; a nil re marks a dimmed context line.
+func printSearchLine(msg map[string]interface{}, names contactNames, re *regexp.Regexp) {
+	ts, _ := msg["time"].(float64)
+	t := time.UnixMilli(int64(ts))
+	who := names.label(str(msg["from"]))
+	arrow := "←"
+	if str(msg["type"]) == "sent" {
+		arrow = "→"
+	}
+	body := strings.Join(strings.Fields(str(msg["body"])), " ")
+
+	if re == nil {
+		dim.Printf("%6s %s  %s %-20s %s\n", "", t.Format("Jan 02 15:04"), arrow, who, body)
+		return
+	}
+	fmt.Printf("%s %s  %s %-20s %s\n",
+		cyan.Sprintf("%6v", msg["thread_id"]),
+		t.Format("Jan 02 15:04"),
+		arrow,
+		cyan.Sprint(who),
+		highlightMatches(body, re),
+	)
+}
+
+func highlightMatches(s string, re *regexp.Regexp) string {
+	locs := re.FindAllStringIndex(s, -1)
+	if len(locs) == 0 {
+		return s
+	}
+	var b strings.Builder
+	last := 0
+	for _, loc := range locs {
+		if loc[0] == loc[1] {
+			continue
+		}
+		b.WriteString(s[last:loc[0]])
+		b.WriteString(highlight.Sprint(s[loc[0]:loc[1]]))
+		last = loc[1]
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
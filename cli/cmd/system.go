@@ -7,12 +7,13 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/phonessh/psh/client"
 	"github.com/spf13/cobra"
 )
 
 var statusCmd = &cobra.Command{
 	Use:   "status",
-	Short: "Show phone status (battery, wifi, storage)",
+	Short: "Show phone status (battery, wifi, storage, calls)",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		c, _ := mustConnect()
 		defer c.Close()
@@ -56,6 +57,20 @@ var statusCmd = &cobra.Command{
 			fmt.Printf("  IP:       %v\n", wifi["ip"])
 			fmt.Printf("  Signal:   %v dBm\n", wifi["rssi"])
 		}
+
+		if call, ok := data["call"].(map[string]interface{}); ok {
+			cyan.Println("\n── Call ─────────────────────────────────────")
+			switch state := str(call["state"]); state {
+			case "ringing":
+				red.Println("  State:    ringing")
+			case "offhook":
+				green.Println("  State:    in call")
+			case "unknown":
+				fmt.Printf("  State:    unknown (%v)\n", call["error"])
+			default:
+				fmt.Printf("  State:    %s\n", state)
+			}
+		}
 		return nil
 	},
 }
@@ -111,20 +126,11 @@ var screenshotCmd = &cobra.Command{
 		defer c.Close()
 
 		fmt.Println("Taking screenshot...")
-		data, err := c.RunRaw(newCmd("screenshot", nil, nil))
+		imgBytes, err := takeScreenshot(c)
 		if err != nil {
 			return err
 		}
 
-		content, ok := data["content"].(string)
-		if !ok {
-			return fmt.Errorf("unexpected response")
-		}
-		imgBytes, err := base64.StdEncoding.DecodeString(content)
-		if err != nil {
-			return fmt.Errorf("decoding image: %w", err)
-		}
-
 		outFile := fmt.Sprintf("screenshot_%s.png",
 			time.Now().Format("20060102_150405"))
 		if len(args) == 1 {
@@ -139,6 +145,23 @@ var screenshotCmd = &cobra.Command{
 	},
 }
 
+// takeScreenshot captures the phone screen as PNG.
+func takeScreenshot(c *client.Client) ([]byte, error) {
+	data, err := c.RunRaw(newCmd("screenshot", nil, nil))
+	if err != nil {
+		return nil, err
+	}
+	content, ok := data["content"].(string)
+	if !ok {
+		return nil, fmt.Errorf("unexpected response")
+	}
+	img, err := base64.StdEncoding.DecodeString(content)
+	if err != nil {
+		return nil, fmt.Errorf("decoding image: %w", err)
+	}
+	return img, nil
+}
+
 var volumeCmd = &cobra.Command{
 	Use:   "volume [get | set <0-100>] [--stream music|ring|alarm]",
 	Short: "Get or set phone volume",
@@ -5,7 +5,9 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/phonessh/psh/selector"
 	"github.com/spf13/cobra"
 )
 
@@ -124,22 +126,44 @@ var typeCmd = &cobra.Command{
 	Short: "Type text into the focused input field",
 	Long: `Set text in the currently focused input field on the phone.
 
-Note: Tap into a text field first (psh tap <x> <y>) before typing.
+Note: Tap into a text field first (psh tap <x> <y>) before typing, or pass
+--into with a selector to tap the field first (see 'psh ui find --help').
 This replaces the entire field content.
 
 Examples:
   psh type "cats"
-  psh type "hello world"`,
+  psh type "hello world"
+  psh type --into "resource-id=com.app:id/search" "cats"`,
 	Args: cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		text := strings.Join(args, " ")
 
+		var sel selector.Selector
+		if into, _ := cmd.Flags().GetString("into"); into != "" {
+			var err error
+			if sel, err = selector.Parse(into); err != nil {
+				return err
+			}
+		}
+
 		c, _, err := getClient()
 		if err != nil {
 			return err
 		}
 		defer c.Close()
 
+		if sel != nil {
+			n, _, err := findOnScreen(c, sel)
+			if err != nil {
+				return err
+			}
+			if _, _, err := tapNode(c, n); err != nil {
+				return err
+			}
+			// Give the field time to take focus and bring up the keyboard
+			time.Sleep(300 * time.Millisecond)
+		}
+
 		result, err := c.Run(newCmd("type", []string{text}, nil))
 		if err != nil {
 			return err
@@ -198,24 +222,51 @@ Examples:
 }
 
 var clickCmd = &cobra.Command{
-	Use:   "click <text>",
-	Short: "Click a UI element by its visible text or description",
+	Use:   "click <text|selector>",
+	Short: "Click a UI element by its text, description or a selector",
 	Long: `Click a UI element by matching its visible text or content description.
 More reliable than tap <x> <y> since it doesn't require coordinates.
 
+A selector (attribute conditions or XPath) is matched against the current
+screen and the center of the first on-screen match is tapped.
+
+` + selectorHelp + `
+
 Examples:
   psh click "Search"
   psh click "Subscribe"
-  psh click "Send"`,
+  psh click "resource-id=com.whatsapp:id/send"
+  psh click 'class=Button && text~="^Sub" && nth=2'
+  psh click "//*[@desc='More options']"`,
 	Args: cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		text := strings.Join(args, " ")
+		sel, err := selector.Parse(text)
+		if err != nil {
+			return err
+		}
 		c, _, err := getClient()
 		if err != nil {
 			return err
 		}
 		defer c.Close()
 
+		if _, plain := sel.(selector.Text); !plain {
+			n, total, err := findOnScreen(c, sel)
+			if err != nil {
+				return err
+			}
+			x, y, err := tapNode(c, n)
+			if err != nil {
+				return err
+			}
+			green.Printf("clicked: %s at (%d, %d)\n", nodeName(n), x, y)
+			if total > 1 {
+				dim.Printf("%d elements matched; add nth=N to pick another\n", total)
+			}
+			return nil
+		}
+
 		result, err := c.Run(newCmd("click", []string{text}, nil))
 		if err != nil {
 			return err
@@ -239,10 +290,13 @@ var uiDumpCmd = &cobra.Command{
 	Long: `List all labelled and clickable UI elements on screen with their
 text, description, class, and center coordinates.
 
-Useful for finding what text to pass to 'psh click'.
+Useful for finding what text to pass to 'psh click'. With --tree the whole
+view hierarchy is printed with class names and resource ids, for writing
+selectors (see 'psh ui find --help').
 
-Example:
-  psh ui dump`,
+Examples:
+  psh ui dump
+  psh ui dump --tree`,
 	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		c, _, err := getClient()
@@ -251,6 +305,16 @@ Example:
 		}
 		defer c.Close()
 
+		if tree, _ := cmd.Flags().GetBool("tree"); tree {
+			t, err := dumpTree(c)
+			if err != nil {
+				return err
+			}
+			printTree(t.Roots(), 1)
+			fmt.Printf("\n  %d nodes\n", len(t.Nodes))
+			return nil
+		}
+
 		result, err := c.Run(newCmd("ui", []string{"dump"}, nil))
 		if err != nil {
 			return err
@@ -293,5 +357,7 @@ Example:
 
 func init() {
 	swipeCmd.Flags().IntVar(&swipeDuration, "duration", 300, "swipe duration in milliseconds")
+	typeCmd.Flags().String("into", "", "selector of the field to tap before typing")
+	uiDumpCmd.Flags().Bool("tree", false, "print the full view hierarchy with resource ids")
 	uiCmd.AddCommand(uiDumpCmd)
 }
@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/phonessh/psh/client"
+	"github.com/phonessh/psh/selector"
+	"github.com/spf13/cobra"
+)
+
+const selectorHelp = `Selectors:
+  Send                                    text or description contains "Send"
+  resource-id=com.app:id/send             attribute equals (id=send also works)
+  class=Button && text~="^Sub"            several conditions, ~= is a regexp
+  text=OK && nth=2                        the second match
+  //LinearLayout/Button[@text='OK']       XPath over the hierarchy
+  //*[contains(@desc,'Play')]/..          parent of a matching node
+  xpath://Button[@text='OK']              XPath; a syntax error is reported
+                                          instead of searching for the text
+
+Attributes: text, desc, resource-id, class, package, hint, and the booleans
+clickable, enabled, focused, checkable, checked, selected, scrollable,
+editable and visible. Class names may omit the package (Button). Use
+'psh ui dump --tree' to see the hierarchy and resource ids.`
+
+var uiFindCmd = &cobra.Command{
+	Use:   "find <selector>",
+	Short: "List UI elements matching a selector",
+	Long: `List the elements on screen that match a selector, with their class,
+resource id, label and center coordinates. Exits with an error when nothing
+matches.
+
+` + selectorHelp + `
+
+Examples:
+  psh ui find "resource-id=com.whatsapp:id/send"
+  psh ui find 'class=Button && text~="^Sub"'
+  psh ui find "//RecyclerView/*[2]" --json`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sel, err := selector.Parse(strings.Join(args, " "))
+		if err != nil {
+			return err
+		}
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		c, _ := mustConnect()
+		defer c.Close()
+
+		tree, err := dumpTree(c)
+		if err != nil {
+			return err
+		}
+		nodes := sel.Find(tree)
+		if len(nodes) == 0 {
+			return fmt.Errorf("no element matches %s", sel)
+		}
+
+		if asJSON {
+			out := make([]map[string]interface{}, len(nodes))
+			for i, n := range nodes {
+				out[i] = nodeJSON(n)
+			}
+			b, _ := json.MarshalIndent(out, "", "  ")
+			fmt.Println(string(b))
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "#\tCLASS\tRESOURCE-ID\tLABEL\tCENTER\n")
+		for _, n := range nodes {
+			x, y := n.Bounds.Center()
+			center := fmt.Sprintf("(%d,%d)", x, y)
+			if n.Bounds.Empty() {
+				center = dim.Sprint("off screen")
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", n.Index, n.ShortClass(), dim.Sprint(n.ID), n.Label(), center)
+		}
+		w.Flush()
+		fmt.Printf("\n%d match(es)\n", len(nodes))
+		return nil
+	},
+}
+
+func init() {
+	uiFindCmd.Flags().Bool("json", false, "print matches as JSON")
+	uiCmd.AddCommand(uiFindCmd)
+}
+
+// dumpTree fetches the full accessibility tree of the active window.
+func dumpTree(c *client.Client) (*selector.Tree, error) {
+	data, err := c.RunRaw(newCmd("ui", []string{"dump"}, map[string]string{"tree": "true"}))
+	if err != nil {
+		return nil, err
+	}
+	raw, _ := data["nodes"].([]interface{})
+	nodes := make([]*selector.Node, 0, len(raw))
+	parents := make([]int, 0, len(raw))
+	for _, r := range raw {
+		m, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		p, _ := m["parent"].(float64)
+		nodes = append(nodes, nodeFromData(m))
+		parents = append(parents, int(p))
+	}
+	return selector.NewTree(nodes, parents), nil
+}
+
+func nodeFromData(m map[string]interface{}) *selector.Node {
+	flag := func(k string) bool {
+		b, _ := m[k].(bool)
+		return b
+	}
+	index, _ := m["index"].(float64)
+	bounds, _ := selector.ParseRect(str(m["bounds"]))
+	return &selector.Node{
+		Index:      int(index),
+		Class:      str(m["class"]),
+		Package:    str(m["package"]),
+		ID:         str(m["id"]),
+		Text:       str(m["text"]),
+		Desc:       str(m["desc"]),
+		Hint:       str(m["hint"]),
+		Bounds:     bounds,
+		Clickable:  flag("clickable"),
+		Enabled:    flag("enabled"),
+		Focused:    flag("focused"),
+		Checkable:  flag("checkable"),
+		Checked:    flag("checked"),
+		Selected:   flag("selected"),
+		Scrollable: flag("scrollable"),
+		Editable:   flag("editable"),
+		Visible:    flag("visible"),
+	}
+}
+
+func nodeJSON(n *selector.Node) map[string]interface{} {
+	x, y := n.Bounds.Center()
+	return map[string]interface{}{
+		"index": n.Index, "class": n.Class, "package": n.Package, "resource_id": n.ID,
+		"text": n.Text, "desc": n.Desc, "hint": n.Hint,
+		"clickable": n.Clickable, "enabled": n.Enabled, "focused": n.Focused,
+		"checked": n.Checked, "selected": n.Selected, "scrollable": n.Scrollable,
+		"editable": n.Editable, "visible": n.Visible,
+		"bounds": []int{n.Bounds.Left, n.Bounds.Top, n.Bounds.Right, n.Bounds.Bottom},
+		"cx":     x, "cy": y,
+	}
+}
+
+// findOnScreen resolves a selector to the first matching element that has
+// on-screen bounds, and also returns how many elements matched in total.
+func findOnScreen(c *client.Client, sel selector.Selector) (*selector.Node, int, error) {
+	tree, err := dumpTree(c)
+	if err != nil {
+		return nil, 0, err
+	}
+	nodes := sel.Find(tree)
+	if len(nodes) == 0 {
+		return nil, 0, fmt.Errorf("no element matches %s", sel)
+	}
+	for _, n := range nodes {
+		if !n.Bounds.Empty() {
+			return n, len(nodes), nil
+		}
+	}
+	return nil, len(nodes), fmt.Errorf("%d element(s) match %s, but none is on screen", len(nodes), sel)
+}
+
+// nodeName describes a node for messages: its label, resource id or class.
+func nodeName(n *selector.Node) string {
+	switch {
+	case n.Label() != "":
+		return n.Label()
+	case n.ID != "":
+		return n.ID
+	}
+	return n.ShortClass()
+}
+
+// tapNode taps the center of a node's bounds.
+func tapNode(c *client.Client, n *selector.Node) (int, int, error) {
+	x, y := n.Bounds.Center()
+	_, err := c.RunRaw(newCmd("tap", []string{strconv.Itoa(x), strconv.Itoa(y)}, nil))
+	return x, y, err
+}
+
+// printTree prints nodes and their descendants indented by depth.
+func printTree(nodes []*selector.Node, depth int) {
+	for _, ch := range nodes {
+		line := ch.ShortClass()
+		if ch.ID != "" {
+			line += " " + cyan.Sprint(ch.ID)
+		}
+		if l := ch.Label(); l != "" {
+			line += fmt.Sprintf(" %q", l)
+		}
+		fmt.Printf("%s%s", strings.Repeat("  ", depth), line)
+		if ch.Bounds.Empty() {
+			fmt.Println()
+		} else {
+			x, y := ch.Bounds.Center()
+			dim.Printf(" (%d,%d)\n", x, y)
+		}
+		printTree(ch.Children, depth+1)
+	}
+}
@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/phonessh/psh/client"
+	"github.com/phonessh/psh/selector"
+	"github.com/spf13/cobra"
+)
+
+// exitTimeout is the exit status of the wait commands when the condition
+// did not hold in time, so scripts can tell it apart from errors (1).
+const exitTimeout = 2
+
+var errWaitTimeout = errors.New("timed out")
+
+var uiWaitForCmd = &cobra.Command{
+	Use:   "wait-for <text|selector>",
+	Short: "Wait until a UI element appears (or disappears with --gone)",
+	Long: `Poll the screen until an element matching the selector is on screen, or
+with --gone until none is.
+
+Exit status: 0 when the condition holds, 2 when --timeout passes first,
+1 on other errors (bad selector, connection lost, accessibility disabled).
+
+` + selectorHelp + `
+
+Examples:
+  psh ui wait-for "Welcome back" && psh click "Continue"
+  psh ui wait-for "resource-id=com.app:id/progress" --gone --timeout 30s
+  psh ui wait-for "//*[@text='Done']" --interval 1s`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sel, err := selector.Parse(strings.Join(args, " "))
+		if err != nil {
+			return err
+		}
+		gone, _ := cmd.Flags().GetBool("gone")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		interval, _ := cmd.Flags().GetDuration("interval")
+
+		c, _ := mustConnect()
+		defer c.Close()
+
+		start := time.Now()
+		n, err := waitForSelector(c, sel, gone, timeout, interval)
+		if errors.Is(err, errWaitTimeout) {
+			red.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitTimeout)
+		}
+		if err != nil {
+			return err
+		}
+		elapsed := time.Since(start).Round(100 * time.Millisecond)
+		if gone {
+			green.Printf("gone: %s (%s)\n", sel, elapsed)
+			return nil
+		}
+		x, y := n.Bounds.Center()
+		green.Printf("found: %s at (%d, %d) (%s)\n", nodeName(n), x, y, elapsed)
+		return nil
+	},
+}
+
+var uiWaitIdleCmd = &cobra.Command{
+	Use:   "wait-idle",
+	Short: "Wait until the screen stops changing",
+	Long: `Poll the view hierarchy until two dumps in a row are identical — useful
+after launching an app or navigating, before reading or clicking.
+
+Exit status: 0 when the screen is idle, 2 when --timeout passes first,
+1 on other errors.
+
+Examples:
+  psh apps launch settings && psh ui wait-idle && psh click "Network"
+  psh ui wait-idle --timeout 20s --interval 500ms`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		interval, _ := cmd.Flags().GetDuration("interval")
+
+		c, _ := mustConnect()
+		defer c.Close()
+
+		start := time.Now()
+		err := waitIdle(c, timeout, interval)
+		if errors.Is(err, errWaitTimeout) {
+			red.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitTimeout)
+		}
+		if err != nil {
+			return err
+		}
+		green.Printf("idle (%s)\n", time.Since(start).Round(100*time.Millisecond))
+		return nil
+	},
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{uiWaitForCmd, uiWaitIdleCmd} {
+		cmd.Flags().Duration("timeout", 10*time.Second, "give up after this long")
+		cmd.Flags().Duration("interval", 300*time.Millisecond, "time between screen dumps")
+		uiCmd.AddCommand(cmd)
+	}
+	uiWaitForCmd.Flags().Bool("gone", false, "wait until no matching element is on screen")
+}
+
+// waitForSelector polls until an element matching sel is on screen (or, with
+// gone, until none is) and returns it. It returns an error wrapping
+// errWaitTimeout when timeout passes first, and other errors right away.
+func waitForSelector(c *client.Client, sel selector.Selector, gone bool, timeout, interval time.Duration) (*selector.Node, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		tree, err := dumpTree(c)
+		if err != nil && !isNoActiveWindow(err) {
+			return nil, err
+		}
+		if err == nil {
+			var found *selector.Node
+			for _, n := range sel.Find(tree) {
+				if !n.Bounds.Empty() {
+					found = n
+					break
+				}
+			}
+			if (found == nil) == gone {
+				return found, nil
+			}
+		}
+
+		if time.Now().Add(interval).After(deadline) {
+			what := "appear"
+			if gone {
+				what = "disappear"
+			}
+			return nil, fmt.Errorf("%w after %s waiting for %s to %s", errWaitTimeout, timeout, sel, what)
+		}
+		time.Sleep(interval)
+	}
+}
+
+// waitIdle polls until two consecutive dumps of the view hierarchy are the
+// same, or returns an error wrapping errWaitTimeout.
+func waitIdle(c *client.Client, timeout, interval time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	var prev uint64
+	havePrev := false
+	for {
+		tree, err := dumpTree(c)
+		if err != nil && !isNoActiveWindow(err) {
+			return err
+		}
+		if err == nil {
+			sum := treeFingerprint(tree)
+			if havePrev && sum == prev {
+				return nil
+			}
+			prev, havePrev = sum, true
+		} else {
+			havePrev = false
+		}
+
+		if time.Now().Add(interval).After(deadline) {
+			return fmt.Errorf("%w after %s: the screen kept changing", errWaitTimeout, timeout)
+		}
+		time.Sleep(interval)
+	}
+}
+
+// isNoActiveWindow reports the daemon error for a dump taken while windows
+// change, which is worth polling through; any other error is not.
+func isNoActiveWindow(err error) bool {
+	return strings.HasPrefix(err.Error(), "no active window")
+}
+
+// treeFingerprint hashes everything about the tree that is visible on screen.
+func treeFingerprint(t *selector.Tree) uint64 {
+	h := fnv.New64a()
+	for _, n := range t.Nodes {
+		fmt.Fprintf(h, "%d|%s|%s|%s|%s|%s|%v|%t%t%t%t\n", n.Parent.Index, n.Class, n.ID, n.Text, n.Desc, n.Hint,
+			n.Bounds, n.Enabled, n.Checked, n.Selected, n.Focused)
+	}
+	return h.Sum64()
+}
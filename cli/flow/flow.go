@@ -0,0 +1,397 @@
+// Package flow loads the YAML UI test flows run by `psh flow run` and
+// writes their results as a JUnit XML report.
+package flow
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/phonessh/psh/selector"
+	"gopkg.in/yaml.v3"
+)
+
+// Actions lists the step types, in the order they are documented.
+var Actions = []string{
+	"launch", "click", "type", "key", "swipe", "assertVisible", "assertNotVisible",
+	"waitFor", "screenshot", "runScript",
+}
+
+// Flow is one flow file.
+type Flow struct {
+	Name  string
+	File  string
+	Env   map[string]string // the file's env: section overridden by Load's vars, for runScript
+	Steps []*Step
+}
+
+// Step is one action of a flow. Target holds the action's value: an app
+// for launch, a selector for click/assert*/waitFor, the text for type, a
+// key name, a screenshot file name or a shell command for runScript.
+type Step struct {
+	Name     string
+	Action   string
+	Target   string
+	Selector selector.Selector // click, assertVisible, assertNotVisible, waitFor; type with into
+	Into     string            // type: field to tap first
+	Swipe    [4]int            // swipe: x1 y1 x2 y2
+	Duration time.Duration     // swipe
+	Timeout  time.Duration     // how long click, type --into, assert* and waitFor wait for the element
+	Gone     bool              // waitFor: wait for the element to disappear
+	Retries  int               // extra attempts after a failure
+
+	// Target and Into before ${VAR} expansion, so logs and reports do not
+	// show secrets passed in variables.
+	rawTarget, rawInto string
+}
+
+// file and rawStep are the YAML layout. A step has exactly one action key;
+// the other keys tune it.
+type file struct {
+	Name  string            `yaml:"name"`
+	Env   map[string]string `yaml:"env"`
+	Steps []rawStep         `yaml:"steps"`
+}
+
+type rawStep struct {
+	Name             string  `yaml:"name"`
+	Launch           *string `yaml:"launch"`
+	Click            *string `yaml:"click"`
+	Type             *string `yaml:"type"`
+	Key              *string `yaml:"key"`
+	Swipe            *string `yaml:"swipe"`
+	AssertVisible    *string `yaml:"assertVisible"`
+	AssertNotVisible *string `yaml:"assertNotVisible"`
+	WaitFor          *string `yaml:"waitFor"`
+	Screenshot       *string `yaml:"screenshot"`
+	RunScript        *string `yaml:"runScript"`
+
+	Into     string `yaml:"into"`
+	Duration string `yaml:"duration"`
+	Timeout  string `yaml:"timeout"`
+	Gone     bool   `yaml:"gone"`
+	Retries  int    `yaml:"retries"`
+}
+
+// Default waits, overridable per step with timeout:.
+const (
+	DefaultTimeout        = 5 * time.Second
+	DefaultWaitForTimeout = 10 * time.Second
+)
+
+var (
+	varRe = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+	keys  = map[string]bool{"back": true, "home": true, "recents": true, "notifications": true}
+)
+
+// Load parses and validates a flow file. ${VAR} in step values is replaced
+// from vars, then the file's env: section, then the environment; runScript
+// commands are left as written for the shell to expand.
+func Load(path string, vars map[string]string) (*Flow, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading flow: %w", err)
+	}
+
+	var f file
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&f); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", filepath.Base(path), err)
+	}
+	if len(f.Steps) == 0 {
+		return nil, fmt.Errorf("%s: no steps", filepath.Base(path))
+	}
+
+	fl := &Flow{Name: f.Name, File: path, Env: map[string]string{}}
+	for k, v := range f.Env {
+		fl.Env[k] = v
+	}
+	for k, v := range vars {
+		fl.Env[k] = v
+	}
+	if fl.Name == "" {
+		fl.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+
+	var missing []string
+	expand := func(s string) string {
+		return varRe.ReplaceAllStringFunc(s, func(m string) string {
+			name := m[2 : len(m)-1]
+			if v, ok := vars[name]; ok {
+				return v
+			}
+			if v, ok := f.Env[name]; ok {
+				return v
+			}
+			if v, ok := os.LookupEnv(name); ok {
+				return v
+			}
+			missing = append(missing, name)
+			return m
+		})
+	}
+
+	for i, r := range f.Steps {
+		st, err := r.compile(expand)
+		if err == nil && len(missing) > 0 {
+			err = fmt.Errorf("undefined variable(s): %s", strings.Join(missing, ", "))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: step %d: %w", filepath.Base(path), i+1, err)
+		}
+		fl.Steps = append(fl.Steps, st)
+	}
+	return fl, nil
+}
+
+func (r rawStep) compile(expand func(string) string) (*Step, error) {
+	st := &Step{Name: r.Name, Retries: r.Retries, Gone: r.Gone, Into: expand(r.Into), rawInto: r.Into}
+	for _, a := range []struct {
+		name  string
+		value *string
+	}{
+		{"launch", r.Launch}, {"click", r.Click}, {"type", r.Type}, {"key", r.Key}, {"swipe", r.Swipe},
+		{"assertVisible", r.AssertVisible}, {"assertNotVisible", r.AssertNotVisible},
+		{"waitFor", r.WaitFor}, {"screenshot", r.Screenshot}, {"runScript", r.RunScript},
+	} {
+		if a.value == nil {
+			continue
+		}
+		if st.Action != "" {
+			return nil, fmt.Errorf("both %s and %s — use one action per step", st.Action, a.name)
+		}
+		st.Action, st.Target, st.rawTarget = a.name, expand(*a.value), *a.value
+		if a.name == "runScript" {
+			// Variables reach the script through its environment; pasting
+			// values into the command line would run them as shell code
+			st.Target = *a.value
+		}
+	}
+	if st.Action == "" {
+		return nil, fmt.Errorf("no action (one of %s)", strings.Join(Actions, ", "))
+	}
+	if st.Retries < 0 {
+		return nil, fmt.Errorf("retries must not be negative")
+	}
+	if st.Target == "" && st.Action != "type" {
+		return nil, fmt.Errorf("%s needs a value", st.Action)
+	}
+	if st.Into != "" && st.Action != "type" {
+		return nil, fmt.Errorf("into only applies to type")
+	}
+	if st.Gone && st.Action != "waitFor" {
+		return nil, fmt.Errorf("gone only applies to waitFor (use assertNotVisible)")
+	}
+
+	var err error
+	st.Timeout = DefaultTimeout
+	if st.Action == "waitFor" {
+		st.Timeout = DefaultWaitForTimeout
+	}
+	if r.Timeout != "" {
+		if st.Timeout, err = time.ParseDuration(r.Timeout); err != nil {
+			return nil, fmt.Errorf("invalid timeout: %w", err)
+		}
+	}
+
+	switch st.Action {
+	case "click", "assertVisible", "assertNotVisible", "waitFor":
+		st.Selector, err = selector.Parse(st.Target)
+	case "type":
+		if st.Into != "" {
+			st.Selector, err = selector.Parse(st.Into)
+		}
+	case "key":
+		if !keys[st.Target] {
+			err = fmt.Errorf("unknown key %q — valid: back, home, recents, notifications", st.Target)
+		}
+	case "swipe":
+		err = st.parseSwipe(r.Duration)
+	case "screenshot":
+		if filepath.Base(st.Target) != st.Target {
+			err = fmt.Errorf("screenshot takes a file name, saved in the output directory")
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if r.Duration != "" && st.Action != "swipe" {
+		return nil, fmt.Errorf("duration only applies to swipe")
+	}
+	return st, nil
+}
+
+func (st *Step) parseSwipe(duration string) error {
+	fields := strings.Fields(st.Target)
+	if len(fields) != 4 {
+		return fmt.Errorf("swipe takes \"x1 y1 x2 y2\"")
+	}
+	for i, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil {
+			return fmt.Errorf("invalid swipe coordinate %q", f)
+		}
+		st.Swipe[i] = n
+	}
+	st.Duration = 300 * time.Millisecond
+	if duration != "" {
+		d, err := time.ParseDuration(duration)
+		if err != nil {
+			return fmt.Errorf("invalid duration: %w", err)
+		}
+		st.Duration = d
+	}
+	return nil
+}
+
+// Describe is a one-line summary of the step for logs and reports.
+func (st *Step) Describe() string {
+	if st.Name != "" {
+		return st.Name
+	}
+	switch {
+	case st.Action == "type" && st.rawInto != "":
+		return fmt.Sprintf("type %q into %s", st.rawTarget, st.rawInto)
+	case st.Action == "type":
+		return fmt.Sprintf("type %q", st.rawTarget)
+	case st.Gone:
+		return fmt.Sprintf("waitFor %s gone", st.rawTarget)
+	}
+	return st.Action + " " + st.rawTarget
+}
+
+// ── results ──────────────────────────────────────────────────────────────────
+
+// Status of a step after a run.
+const (
+	Passed  = "passed"
+	Failed  = "failed"
+	Skipped = "skipped"
+)
+
+// Result is the outcome of running one flow.
+type Result struct {
+	Flow    *Flow
+	Device  string
+	Started time.Time
+	Steps   []StepResult
+}
+
+// StepResult is the outcome of one step.
+type StepResult struct {
+	Step       *Step
+	Status     string
+	Attempts   int
+	Duration   time.Duration
+	Err        error
+	Screenshot string // saved on failure, if taking it worked
+	Output     string // runScript output
+}
+
+// Failed reports whether any step of the flow failed.
+func (r *Result) Failed() bool {
+	for _, s := range r.Steps {
+		if s.Status == Failed {
+			return true
+		}
+	}
+	return false
+}
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Time     string       `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name      string      `xml:"name,attr"`
+	Tests     int         `xml:"tests,attr"`
+	Failures  int         `xml:"failures,attr"`
+	Skipped   int         `xml:"skipped,attr"`
+	Time      string      `xml:"time,attr"`
+	Timestamp string      `xml:"timestamp,attr"`
+	Hostname  string      `xml:"hostname,attr,omitempty"`
+	File      string      `xml:"file,attr"`
+	Cases     []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure"`
+	Skipped   *struct{}     `xml:"skipped"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func seconds(d time.Duration) string { return strconv.FormatFloat(d.Seconds(), 'f', 3, 64) }
+
+// WriteJUnit writes the results as JUnit XML: one testsuite per flow and
+// one testcase per step, so CI shows exactly which step broke.
+func WriteJUnit(path string, results []*Result) error {
+	root := junitSuites{Name: "psh flow"}
+	var total time.Duration
+	for _, r := range results {
+		s := junitSuite{
+			Name:      r.Flow.Name,
+			File:      r.Flow.File,
+			Hostname:  r.Device,
+			Timestamp: r.Started.Format("2006-01-02T15:04:05"),
+		}
+		var elapsed time.Duration
+		for i, sr := range r.Steps {
+			c := junitCase{
+				Name:      fmt.Sprintf("%02d %s", i+1, sr.Step.Describe()),
+				Classname: r.Flow.Name,
+				Time:      seconds(sr.Duration),
+				SystemOut: sr.Output,
+			}
+			switch sr.Status {
+			case Failed:
+				detail := fmt.Sprintf("attempts: %d", sr.Attempts)
+				if sr.Screenshot != "" {
+					detail += "\nscreenshot: " + sr.Screenshot
+				}
+				c.Failure = &junitFailure{Message: sr.Err.Error(), Type: sr.Step.Action, Text: detail}
+				s.Failures++
+			case Skipped:
+				c.Skipped = &struct{}{}
+				s.Skipped++
+			}
+			elapsed += sr.Duration
+			s.Cases = append(s.Cases, c)
+		}
+		s.Tests = len(s.Cases)
+		s.Time = seconds(elapsed)
+		root.Tests += s.Tests
+		root.Failures += s.Failures
+		root.Skipped += s.Skipped
+		total += elapsed
+		root.Suites = append(root.Suites, s)
+	}
+	root.Time = seconds(total)
+
+	out, err := xml.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append([]byte(xml.Header), append(out, '\n')...), 0644)
+}
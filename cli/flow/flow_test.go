@@ -0,0 +1,83 @@
+package flow
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFlow(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "login.yaml")
+	if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name, body, want string
+	}{
+		{"no steps", "name: x\n", "no steps"},
+		{"unknown top-level key", "nme: x\nsteps:\n  - click: OK\n", "field nme not found"},
+		{"unknown step key", "steps:\n  - clik: OK\n", "field clik not found"},
+		{"two actions", "steps:\n  - click: OK\n    waitFor: Done\n", "both click and waitFor"},
+		{"no action", "steps:\n  - timeout: 1s\n", "no action"},
+		{"undefined variable", "steps:\n  - type: ${PSH_TEST_UNSET}\n", "undefined variable(s): PSH_TEST_UNSET"},
+		{"undefined variable in runScript", "steps:\n  - runScript: echo ${PSH_TEST_UNSET}\n", "undefined variable(s): PSH_TEST_UNSET"},
+		{"empty value", "steps:\n  - click: \"\"\n", "click needs a value"},
+		{"bad selector", "steps:\n  - click: \"text=OK && nth=0\"\n", "nth must be a number"},
+		{"unknown key name", "steps:\n  - key: enter\n", "unknown key \"enter\""},
+		{"bad swipe", "steps:\n  - swipe: \"1 2 3\"\n", "x1 y1 x2 y2"},
+		{"duration without swipe", "steps:\n  - click: OK\n    duration: 1s\n", "duration only applies to swipe"},
+		{"into without type", "steps:\n  - click: OK\n    into: id=name\n", "into only applies to type"},
+		{"gone without waitFor", "steps:\n  - click: OK\n    gone: true\n", "gone only applies to waitFor"},
+		{"negative retries", "steps:\n  - click: OK\n    retries: -1\n", "retries must not be negative"},
+		{"bad timeout", "steps:\n  - click: OK\n    timeout: soon\n", "invalid timeout"},
+		{"screenshot path", "steps:\n  - screenshot: ../x.png\n", "takes a file name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writeFlow(t, tt.body), nil)
+			if err == nil {
+				t.Fatalf("Load succeeded, want an error containing %q", tt.want)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load error %q, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadVariables(t *testing.T) {
+	t.Setenv("PSH_TEST_USER", "from-environment")
+	t.Setenv("PSH_TEST_HOST", "from-environment")
+	path := writeFlow(t, `env:
+  PSH_TEST_USER: from-file
+  PASSWORD: from-file
+steps:
+  - type: ${PSH_TEST_USER}:${PASSWORD}@${PSH_TEST_HOST}
+  - runScript: ./check.sh "${PASSWORD}"
+`)
+	fl, err := Load(path, map[string]string{"PASSWORD": "from-flag"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := fl.Steps[0].Target, "from-file:from-flag@from-environment"; got != want {
+		t.Errorf("type target %q, want %q", got, want)
+	}
+	if got, want := fl.Steps[0].Describe(), `type "${PSH_TEST_USER}:${PASSWORD}@${PSH_TEST_HOST}"`; got != want {
+		t.Errorf("Describe() %q, want %q", got, want)
+	}
+	if got, want := fl.Steps[1].Target, `./check.sh "${PASSWORD}"`; got != want {
+		t.Errorf("runScript target %q, want it unexpanded %q", got, want)
+	}
+	if fl.Env["PASSWORD"] != "from-flag" || fl.Env["PSH_TEST_USER"] != "from-file" {
+		t.Errorf("Env %v, want PASSWORD from the flag and PSH_TEST_USER from the file", fl.Env)
+	}
+	if fl.Name != "login" {
+		t.Errorf("Name %q, want the file name", fl.Name)
+	}
+}
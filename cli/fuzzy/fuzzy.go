@@ -0,0 +1,133 @@
+// Package fuzzy ranks free-text queries against names such as contacts or
+// app labels.
+package fuzzy
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Score rates how well query matches candidate; 0 means no match.
+// Higher is better: exact > prefix > word prefix > substring > typo > subsequence.
+func Score(query, candidate string) int {
+	q := normalize(query)
+	c := normalize(candidate)
+	if q == "" || c == "" {
+		return 0
+	}
+
+	switch {
+	case q == c:
+		return 1000
+	case strings.HasPrefix(c, q):
+		return 800 - min(len(c)-len(q), 100)
+	}
+	for _, w := range strings.Fields(c) {
+		if strings.HasPrefix(w, q) {
+			return 600 - min(len(c)-len(q), 100)
+		}
+	}
+	if strings.Contains(c, q) {
+		return 400 - min(len(c)-len(q), 100)
+	}
+	if len(q) >= 4 {
+		for _, w := range strings.Fields(c) {
+			if levenshtein(q, w) <= 1 {
+				return 300
+			}
+		}
+	}
+	if s := subsequence(q, c); s > 0 {
+		return s
+	}
+	return 0
+}
+
+// Match is a ranked candidate.
+type Match struct {
+	Index int // position in the input slice
+	Name  string
+	Score int
+}
+
+// Rank returns every candidate that matches query, best first.
+func Rank(query string, candidates []string) []Match {
+	var out []Match
+	for i, c := range candidates {
+		if s := Score(query, c); s > 0 {
+			out = append(out, Match{Index: i, Name: c, Score: s})
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Score > out[j].Score })
+	return out
+}
+
+// MinConfident is the lowest score used without asking: a prefix of the
+// name or of one of its words. Substring, typo and subsequence matches are
+// only suggestions, however few candidates there are.
+const MinConfident = 500
+
+// Confident reports whether the best match is strong enough and clearly
+// beats the runner-up, so it can be used without asking the user.
+func Confident(matches []Match) bool {
+	switch {
+	case len(matches) == 0 || matches[0].Score < MinConfident:
+		return false
+	case len(matches) == 1:
+		return true
+	case matches[0].Score == 1000:
+		return matches[1].Score < 1000
+	}
+	return matches[0].Score-matches[1].Score >= 200
+}
+
+// normalize lowercases and turns punctuation (dots in package names,
+// dashes, underscores) into spaces so words can be matched separately.
+func normalize(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	return strings.Join(strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// subsequence scores q as an in-order subsequence of c, favouring runs of
+// consecutive characters. Returns 0 when q is not a subsequence.
+func subsequence(q, c string) int {
+	qr, cr := []rune(q), []rune(c)
+	score, run, qi := 0, 0, 0
+	for ci := 0; ci < len(cr) && qi < len(qr); ci++ {
+		if cr[ci] == qr[qi] {
+			qi++
+			run++
+			score += run
+		} else {
+			run = 0
+		}
+	}
+	if qi < len(qr) {
+		return 0
+	}
+	return min(100+score*4, 290)
+}
+
+func levenshtein(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	prev := make([]int, len(br)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ar); i++ {
+		cur := make([]int, len(br)+1)
+		cur[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(br)]
+}
@@ -0,0 +1,210 @@
+// Package history keeps a local SQLite archive of phone notifications so they
+// can be searched after they have been dismissed on the device.
+package history
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/phonessh/psh/client"
+	_ "modernc.org/sqlite"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS notifications (
+	id        INTEGER PRIMARY KEY AUTOINCREMENT,
+	device    TEXT    NOT NULL,
+	key       TEXT    NOT NULL,
+	app       TEXT    NOT NULL,
+	title     TEXT    NOT NULL DEFAULT '',
+	text      TEXT    NOT NULL DEFAULT '',
+	posted_at INTEGER NOT NULL,
+	seen_at   INTEGER NOT NULL,
+	UNIQUE(device, key, posted_at)
+);
+CREATE INDEX IF NOT EXISTS idx_notifications_app_posted ON notifications(app, posted_at);
+CREATE INDEX IF NOT EXISTS idx_notifications_posted ON notifications(posted_at);
+`
+
+// Notification is one archived notification.
+type Notification struct {
+	Device string
+	Key    string
+	App    string
+	Title  string
+	Text   string
+	Posted time.Time
+	Seen   time.Time
+}
+
+// Store is an open history database.
+type Store struct {
+	db *sql.DB
+}
+
+// Path returns the location of the history database inside the config dir.
+func Path() (string, error) {
+	dir, err := client.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "history.db"), nil
+}
+
+// Open opens (creating if needed) the history database.
+func Open() (*Store, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("creating config dir: %w", err)
+	}
+
+	db, err := sql.Open("sqlite", path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, fmt.Errorf("opening history: %w", err)
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("initialising history: %w", err)
+	}
+	return &Store{db: db}, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// AddNotifications archives notifications, skipping ones already stored.
+// Returns the number of newly inserted rows.
+func (s *Store) AddNotifications(ns []Notification) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`INSERT OR IGNORE INTO notifications
+		(device, key, app, title, text, posted_at, seen_at) VALUES (?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	added := 0
+	now := time.Now()
+	for _, n := range ns {
+		seen := n.Seen
+		if seen.IsZero() {
+			seen = now
+		}
+		res, err := stmt.Exec(n.Device, n.Key, n.App, n.Title, n.Text, n.Posted.UnixMilli(), seen.UnixMilli())
+		if err != nil {
+			return 0, fmt.Errorf("inserting notification: %w", err)
+		}
+		if rows, _ := res.RowsAffected(); rows > 0 {
+			added++
+		}
+	}
+	return added, tx.Commit()
+}
+
+// Query filters archived notifications. Zero values mean "no filter".
+type Query struct {
+	App   string    // substring of the package name
+	Grep  string    // substring of the title or text (case-insensitive)
+	Since time.Time // only notifications posted at or after this time
+	Limit int
+}
+
+// Notifications returns archived notifications matching q, newest first.
+func (s *Store) Notifications(q Query) ([]Notification, error) {
+	where, args := q.where()
+	sqlStr := `SELECT device, key, app, title, text, posted_at, seen_at FROM notifications` +
+		where + ` ORDER BY posted_at DESC`
+	if q.Limit > 0 {
+		sqlStr += fmt.Sprintf(" LIMIT %d", q.Limit)
+	}
+
+	rows, err := s.db.Query(sqlStr, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Notification
+	for rows.Next() {
+		var n Notification
+		var posted, seen int64
+		if err := rows.Scan(&n.Device, &n.Key, &n.App, &n.Title, &n.Text, &posted, &seen); err != nil {
+			return nil, err
+		}
+		n.Posted = time.UnixMilli(posted)
+		n.Seen = time.UnixMilli(seen)
+		out = append(out, n)
+	}
+	return out, rows.Err()
+}
+
+// DayCount is the number of notifications an app posted on one local day.
+type DayCount struct {
+	Day   string // YYYY-MM-DD, local time
+	App   string
+	Count int
+}
+
+// DailyCounts groups matching notifications per app per day, newest day first.
+func (s *Store) DailyCounts(q Query) ([]DayCount, error) {
+	where, args := q.where()
+	rows, err := s.db.Query(`SELECT date(posted_at / 1000, 'unixepoch', 'localtime') AS day, app, COUNT(*)
+		FROM notifications`+where+`
+		GROUP BY day, app ORDER BY day DESC, COUNT(*) DESC`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []DayCount
+	for rows.Next() {
+		var dc DayCount
+		if err := rows.Scan(&dc.Day, &dc.App, &dc.Count); err != nil {
+			return nil, err
+		}
+		out = append(out, dc)
+	}
+	return out, rows.Err()
+}
+
+// likeContains is a LIKE pattern matching s anywhere, with s's own % and _
+// taken literally.
+func likeContains(s string) string {
+	return "%" + likeEscaper.Replace(s) + "%"
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+func (q Query) where() (string, []interface{}) {
+	var conds []string
+	var args []interface{}
+	if q.App != "" {
+		conds = append(conds, `app LIKE ? ESCAPE '\'`)
+		args = append(args, likeContains(q.App))
+	}
+	if q.Grep != "" {
+		conds = append(conds, `(title LIKE ? ESCAPE '\' OR text LIKE ? ESCAPE '\')`)
+		args = append(args, likeContains(q.Grep), likeContains(q.Grep))
+	}
+	if !q.Since.IsZero() {
+		conds = append(conds, "posted_at >= ?")
+		args = append(args, q.Since.UnixMilli())
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
@@ -0,0 +1,110 @@
+// Package otp extracts one-time passcodes from SMS and notification text.
+package otp
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Match is a code found in a message.
+type Match struct {
+	Code   string
+	Locale string // which pattern set matched, e.g. "en", "de", "zh"
+}
+
+type pattern struct {
+	locale string
+	re     *regexp.Regexp // first capture group is the code
+}
+
+// Codes are 4–8 digits, optionally split once by a space or hyphen
+// ("123 456", "123-456"), or 4–10 uppercase alphanumerics containing a digit.
+const digits = `(\d{3,4}[ -]\d{3,4}|\d{4,8})`
+const alnum = `([A-Z0-9]{4,10})`
+
+// Keyword-anchored patterns, tried in order. Most specific first.
+var patterns = []pattern{
+	// Google style "G-123456"
+	{"en", regexp.MustCompile(`\bG-(\d{6})\b`)},
+
+	// "123456 is your verification code" / "123456 est votre code"
+	{"en", regexp.MustCompile(`(?i)\b` + digits + `\s+is\s+your\b`)},
+	{"fr", regexp.MustCompile(`(?i)\b` + digits + `\s+est\s+votre\b`)},
+	{"de", regexp.MustCompile(`(?i)\b` + digits + `\s+ist\s+(?:dein|ihr)\b`)},
+	{"es", regexp.MustCompile(`(?i)\b` + digits + `\s+es\s+tu\b`)},
+	{"pt", regexp.MustCompile(`(?i)\b` + digits + `\s+(?:é|e)\s+o\s+seu\b`)},
+
+	// "Use 123456 to sign in", "Enter 1234 as your PIN"
+	{"en", regexp.MustCompile(`(?i)\b(?:use|enter)\s+` + digits + `\s+(?:to|as|for)\b`)},
+
+	// "code: 123456", "your code is 123456", "OTP 1234"
+	{"en", regexp.MustCompile(`(?i)\b(?:verification|security|login|sign-in|confirmation|access|auth(?:entication)?|one[- ]time)?\s*(?:code|passcode|otp|pin|token)\b[^0-9A-Za-z]{0,3}(?:is|was)?[\s:#-]*` + digits + `\b`)},
+	{"de", regexp.MustCompile(`(?i)\b(?:bestätigungscode|sicherheitscode|anmeldecode|code|tan|pin)\b\s*(?:lautet|ist)?[\s:#-]*` + digits + `\b`)},
+	{"fr", regexp.MustCompile(`(?i)\bcode(?:\s+de\s+(?:vérification|verification|confirmation|sécurité|securite|connexion))?\s*(?:est)?[\s:#-]*` + digits + `\b`)},
+	{"es", regexp.MustCompile(`(?i)\b(?:código|codigo|clave)(?:\s+de\s+(?:verificación|verificacion|seguridad|acceso))?\s*(?:es)?[\s:#-]*` + digits + `\b`)},
+	{"pt", regexp.MustCompile(`(?i)\b(?:código|codigo)(?:\s+de\s+(?:verificação|verificacao|segurança|seguranca|acesso))?\s*(?:é|e)?[\s:#-]*` + digits + `\b`)},
+	{"it", regexp.MustCompile(`(?i)\bcodice(?:\s+di\s+(?:verifica|sicurezza|accesso))?\s*(?:è|e)?[\s:#-]*` + digits + `\b`)},
+	{"nl", regexp.MustCompile(`(?i)\b(?:verificatiecode|beveiligingscode|code)\s*(?:is)?[\s:#-]*` + digits + `\b`)},
+	{"pl", regexp.MustCompile(`(?i)\bkod(?:\s+(?:weryfikacyjny|dostępu|sms))?\s*(?:to)?[\s:#-]*` + digits + `\b`)},
+	{"ru", regexp.MustCompile(`(?i)(?:код(?:\s+подтверждения)?|пароль)\s*(?:[:\-–—]|это)?\s*` + digits)},
+	// \b is ASCII-only in Go and never matches before "ş"
+	{"tr", regexp.MustCompile(`(?i)(?:^|\W)(?:doğrulama\s+kodu|kodu|şifre(?:niz)?)\s*[:\-]?\s*` + digits + `\b`)},
+
+	// CJK — no word boundaries, keyword within a few characters of the code
+	{"zh", regexp.MustCompile(`(?:验证码|驗證碼|校验码|动态码)[^0-9]{0,8}(\d{4,8})`)},
+	{"zh", regexp.MustCompile(`(\d{4,8})[^0-9]{0,4}(?:是您的|为您的|為您的)?(?:验证码|驗證碼)`)},
+	{"ja", regexp.MustCompile(`(?:認証コード|確認コード|ワンタイムパスワード|認証番号)[^0-9]{0,8}(\d{4,8})`)},
+	{"ko", regexp.MustCompile(`(?:인증\s*번호|인증\s*코드|확인\s*코드)[^0-9]{0,8}(\d{4,8})`)},
+
+	// Alphanumeric codes right after a keyword: "code: AB12CD"
+	{"en", regexp.MustCompile(`(?i)\b(?:code|passcode|otp)\b\s*(?:is)?[\s:#-]+` + alnum + `\b`)},
+}
+
+// keywords mark a message as likely to contain a code even when no
+// anchored pattern fits; the first plausible number is then used.
+var keywords = regexp.MustCompile(`(?i)(otp|passcode|one[- ]time|verif|2fa|two[- ]factor|security code|login code|code|código|codice|kod|код|验证码|驗證碼|認証|인증)`)
+
+var looseDigits = regexp.MustCompile(`(^|[^0-9+$€£¥.,:/])(\d{4,8})([^0-9%.,:/]|$)`)
+
+// Extract returns the most likely one-time code in text.
+func Extract(text string) (Match, bool) {
+	for _, p := range patterns {
+		m := p.re.FindStringSubmatch(text)
+		if m == nil {
+			continue
+		}
+		code := normalize(m[1])
+		if plausible(code) {
+			return Match{Code: code, Locale: p.locale}, true
+		}
+	}
+
+	if !keywords.MatchString(text) {
+		return Match{}, false
+	}
+	for _, m := range looseDigits.FindAllStringSubmatch(text, -1) {
+		if plausible(m[2]) {
+			return Match{Code: m[2], Locale: "any"}, true
+		}
+	}
+	return Match{}, false
+}
+
+func normalize(code string) string {
+	return strings.NewReplacer(" ", "", "-", "").Replace(code)
+}
+
+// plausible rejects strings that matched a pattern but are unlikely codes.
+func plausible(code string) bool {
+	if len(code) < 4 || len(code) > 10 {
+		return false
+	}
+	hasDigit := false
+	for _, r := range code {
+		if r >= '0' && r <= '9' {
+			hasDigit = true
+			break
+		}
+	}
+	return hasDigit
+}
@@ -0,0 +1,56 @@
+package otp
+
+import "testing"
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		text   string
+		code   string
+		locale string
+	}{
+		{"G-482913 is your Google verification code.", "482913", "en"},
+		{"482913 is your Instagram code", "482913", "en"},
+		{"Use 7731 to sign in to Example", "7731", "en"},
+		{"Your verification code is: 123-456", "123456", "en"},
+		{"Your login code: AB12CD", "AB12CD", "en"},
+		{"Ihr Bestätigungscode lautet 583920", "583920", "de"},
+		{"482913 ist dein Code für Example", "482913", "de"},
+		{"Votre code de vérification est 739201", "739201", "fr"},
+		{"Tu código de verificación es 662810", "662810", "es"},
+		{"Seu código de segurança é 552901", "552901", "pt"},
+		{"Il tuo codice di verifica è 918273", "918273", "it"},
+		{"Je verificatiecode is 334455", "334455", "nl"},
+		{"Twój kod weryfikacyjny to 776655", "776655", "pl"},
+		{"Код подтверждения: 4821", "4821", "ru"},
+		{"Doğrulama kodu: 665544", "665544", "tr"},
+		{"Şifreniz 239847 olarak belirlendi", "239847", "tr"},
+		{"Tek kullanımlık şifre: 918273", "918273", "tr"},
+		{"【Example】您的验证码是 839201，5分钟内有效", "839201", "zh"},
+		{"839201是您的验证码", "839201", "zh"},
+		{"認証コード：572910", "572910", "ja"},
+		{"[Example] 인증번호 [482913]를 입력해주세요", "482913", "ko"},
+		{"Your one-time passcode for Example, valid 10 min: 8812", "8812", "any"},
+	}
+	for _, tt := range tests {
+		m, ok := Extract(tt.text)
+		if !ok {
+			t.Errorf("Extract(%q): no code, want %s", tt.text, tt.code)
+			continue
+		}
+		if m.Code != tt.code || m.Locale != tt.locale {
+			t.Errorf("Extract(%q) = %s (%s), want %s (%s)", tt.text, m.Code, m.Locale, tt.code, tt.locale)
+		}
+	}
+}
+
+func TestExtractNoCode(t *testing.T) {
+	for _, text := range []string{
+		"Your order of $1299 has shipped",
+		"Meeting moved to 14:30 tomorrow",
+		"Call me at 555-1234 when you can",
+	} {
+		if m, ok := Extract(text); ok {
+			t.Errorf("Extract(%q) = %s, want no code", text, m.Code)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+// Package rules loads and evaluates the notification rules run by `psh agent`.
+package rules
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/phonessh/psh/client"
+	"gopkg.in/yaml.v3"
+)
+
+// File is the root of rules.yaml / rules.json.
+type File struct {
+	Rules []*Rule `json:"rules" yaml:"rules"`
+}
+
+// Rule matches incoming notifications and lists the actions to run.
+// All set conditions must match; an empty condition matches anything.
+type Rule struct {
+	Name     string   `json:"name" yaml:"name"`
+	App      string   `json:"app,omitempty" yaml:"app,omitempty"`     // substring of the package name
+	Title    string   `json:"title,omitempty" yaml:"title,omitempty"` // regular expression
+	Text     string   `json:"text,omitempty" yaml:"text,omitempty"`   // regular expression
+	Cooldown string   `json:"cooldown,omitempty" yaml:"cooldown,omitempty"`
+	Actions  []Action `json:"actions" yaml:"actions"`
+
+	titleRe  *regexp.Regexp
+	textRe   *regexp.Regexp
+	cooldown time.Duration
+}
+
+// Action is exactly one of a local shell command, a psh command, or a webhook.
+type Action struct {
+	Shell   string   `json:"shell,omitempty" yaml:"shell,omitempty"`
+	Psh     string   `json:"psh,omitempty" yaml:"psh,omitempty"`
+	Webhook *Webhook `json:"webhook,omitempty" yaml:"webhook,omitempty"`
+}
+
+// Webhook is an HTTP request fired by a rule. Body is a text/template.
+type Webhook struct {
+	URL     string            `json:"url" yaml:"url"`
+	Method  string            `json:"method,omitempty" yaml:"method,omitempty"`
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Body    string            `json:"body,omitempty" yaml:"body,omitempty"`
+}
+
+// Event is the notification a rule is evaluated against. Its fields are
+// available to templates as {{.App}}, {{.Title}}, {{.Text}}, {{.Key}}, {{.Time}}.
+type Event struct {
+	Key   string
+	App   string
+	Title string
+	Text  string
+	Time  time.Time
+}
+
+// Path returns the rules file in the config dir. rules.yaml, rules.yml and
+// rules.json are tried in that order; rules.yaml is returned if none exist.
+func Path() (string, error) {
+	dir, err := client.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	for _, name := range []string{"rules.yaml", "rules.yml", "rules.json"} {
+		p := filepath.Join(dir, name)
+		if _, err := os.Stat(p); err == nil {
+			return p, nil
+		}
+	}
+	return filepath.Join(dir, "rules.yaml"), nil
+}
+
+// Load parses and validates a rules file.
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading rules: %w", err)
+	}
+
+	var f File
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Unmarshal(data, &f)
+	} else {
+		err = yaml.Unmarshal(data, &f)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", filepath.Base(path), err)
+	}
+
+	for i, r := range f.Rules {
+		if r.Name == "" {
+			r.Name = fmt.Sprintf("rule-%d", i+1)
+		}
+		if err := r.compile(); err != nil {
+			return nil, fmt.Errorf("rule %q: %w", r.Name, err)
+		}
+	}
+	return &f, nil
+}
+
+func (r *Rule) compile() error {
+	var err error
+	if r.Title != "" {
+		if r.titleRe, err = regexp.Compile(r.Title); err != nil {
+			return fmt.Errorf("invalid title regex: %w", err)
+		}
+	}
+	if r.Text != "" {
+		if r.textRe, err = regexp.Compile(r.Text); err != nil {
+			return fmt.Errorf("invalid text regex: %w", err)
+		}
+	}
+	if r.Cooldown != "" {
+		if r.cooldown, err = time.ParseDuration(r.Cooldown); err != nil {
+			return fmt.Errorf("invalid cooldown: %w", err)
+		}
+	}
+	if len(r.Actions) == 0 {
+		return fmt.Errorf("no actions")
+	}
+	for i, a := range r.Actions {
+		n := 0
+		if a.Shell != "" {
+			n++
+		}
+		if a.Psh != "" {
+			n++
+		}
+		if a.Webhook != nil {
+			n++
+			if a.Webhook.URL == "" {
+				return fmt.Errorf("action %d: webhook needs a url", i+1)
+			}
+			for _, tmpl := range []string{a.Webhook.URL, a.Webhook.Body} {
+				if _, err := parseTemplate(tmpl); err != nil {
+					return fmt.Errorf("action %d: webhook: %w", i+1, err)
+				}
+			}
+		}
+		if n != 1 {
+			return fmt.Errorf("action %d: set exactly one of shell, psh or webhook", i+1)
+		}
+	}
+	return nil
+}
+
+// Matches reports whether the event satisfies every condition of the rule.
+func (r *Rule) Matches(e Event) bool {
+	if r.App != "" && !strings.Contains(strings.ToLower(e.App), strings.ToLower(r.App)) {
+		return false
+	}
+	if r.titleRe != nil && !r.titleRe.MatchString(e.Title) {
+		return false
+	}
+	if r.textRe != nil && !r.textRe.MatchString(e.Text) {
+		return false
+	}
+	return true
+}
+
+// CooldownPeriod is the minimum time between two firings of the rule.
+func (r *Rule) CooldownPeriod() time.Duration { return r.cooldown }
+
+// templateFuncs are available in action templates. json renders a value as a
+// JSON literal, so {{json .Text}} is a quoted, escaped string in webhook bodies.
+var templateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+func parseTemplate(tmpl string) (*template.Template, error) {
+	return template.New("action").Funcs(templateFuncs).Option("missingkey=error").Parse(tmpl)
+}
+
+// Expand renders a text/template string against the event.
+func Expand(tmpl string, e Event) (string, error) {
+	if !strings.Contains(tmpl, "{{") {
+		return tmpl, nil
+	}
+	t, err := parseTemplate(tmpl)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, e); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// Describe is a one-line summary of the action for logs.
+func (a Action) Describe() string {
+	switch {
+	case a.Shell != "":
+		return "shell: " + a.Shell
+	case a.Psh != "":
+		return "psh " + a.Psh
+	case a.Webhook != nil:
+		method := a.Webhook.Method
+		if method == "" {
+			method = "POST"
+		}
+		return "webhook: " + method + " " + a.Webhook.URL
+	}
+	return "(empty)"
+}
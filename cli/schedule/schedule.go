@@ -0,0 +1,201 @@
+// Package schedule keeps SMS queued on this computer for later delivery by
+// 'psh agent'.
+package schedule
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/phonessh/psh/client"
+)
+
+// Message statuses.
+const (
+	Pending   = "pending"
+	Sending   = "sending" // claimed by an agent; never sent twice
+	Sent      = "sent"
+	Failed    = "failed"
+	Cancelled = "cancelled"
+)
+
+// keepDone is how long sent, failed and cancelled messages stay in the file.
+const keepDone = 7 * 24 * time.Hour
+
+// Message is one scheduled SMS.
+type Message struct {
+	ID      int       `json:"id"`
+	Device  string    `json:"device"`
+	To      string    `json:"to"`
+	Label   string    `json:"label,omitempty"` // contact name, for display
+	Body    string    `json:"body"`
+	At      time.Time `json:"at"`
+	Created time.Time `json:"created"`
+	Status  string    `json:"status"`
+	Error   string    `json:"error,omitempty"`
+	Done    time.Time `json:"done,omitempty"`
+}
+
+// Display is the contact name when known, else the number.
+func (m *Message) Display() string {
+	if m.Label != "" {
+		return m.Label
+	}
+	return m.To
+}
+
+// Path returns the location of the schedule file in the psh config dir.
+func Path() (string, error) {
+	dir, err := client.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "scheduled.json"), nil
+}
+
+// Load returns every scheduled message, oldest first. A missing file is
+// an empty schedule.
+func Load() ([]*Message, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var msgs []*Message
+	if err := json.Unmarshal(data, &msgs); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return msgs, nil
+}
+
+// Update loads the schedule, lets fn modify it, and writes it back
+// atomically. It holds a lock file throughout, so a CLI adding a message and
+// the agent marking one sent cannot overwrite each other's change. Finished
+// messages older than a week are dropped.
+func Update(fn func(msgs []*Message) ([]*Message, error)) error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	unlock, err := lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	msgs, err := Load()
+	if err != nil {
+		return err
+	}
+	msgs, err = fn(msgs)
+	if err != nil {
+		return err
+	}
+
+	kept := msgs[:0]
+	for _, m := range msgs {
+		if m.Status != Pending && time.Since(m.At) > keepDone {
+			continue
+		}
+		kept = append(kept, m)
+	}
+
+	data, err := json.MarshalIndent(kept, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Lock timing: how long Update waits for another process, and the age at
+// which a lock file is assumed to be left over from a crash.
+const (
+	lockWait  = 10 * time.Second
+	lockStale = time.Minute
+)
+
+// lock creates path.lock exclusively and returns a func that removes it.
+func lock(path string) (func(), error) {
+	lockPath := path + ".lock"
+	deadline := time.Now().Add(lockWait)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		if fi, err := os.Stat(lockPath); err == nil && time.Since(fi.ModTime()) > lockStale {
+			os.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%s is locked by another psh process (remove %s if none is running)", path, lockPath)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// Add appends m as a pending message and assigns its ID.
+func Add(m *Message) error {
+	return Update(func(msgs []*Message) ([]*Message, error) {
+		for _, existing := range msgs {
+			if existing.ID >= m.ID {
+				m.ID = existing.ID + 1
+			}
+		}
+		if m.ID == 0 {
+			m.ID = 1
+		}
+		m.Status = Pending
+		m.Created = time.Now()
+		return append(msgs, m), nil
+	})
+}
+
+// Finish records the outcome of sending a message claimed as Sending.
+func Finish(id int, status, errText string) error {
+	return Update(func(msgs []*Message) ([]*Message, error) {
+		for _, m := range msgs {
+			if m.ID == id {
+				m.Status, m.Error, m.Done = status, errText, time.Now()
+				return msgs, nil
+			}
+		}
+		return nil, fmt.Errorf("no scheduled message with id %d", id)
+	})
+}
+
+// Cancel marks a pending message as cancelled.
+func Cancel(id int) error {
+	return Update(func(msgs []*Message) ([]*Message, error) {
+		for _, m := range msgs {
+			if m.ID == id {
+				if m.Status != Pending {
+					return nil, fmt.Errorf("scheduled message %d is already %s", id, m.Status)
+				}
+				m.Status = Cancelled
+				m.Done = time.Now()
+				return msgs, nil
+			}
+		}
+		return nil, fmt.Errorf("no scheduled message with id %d", id)
+	})
+}
@@ -0,0 +1,686 @@
+// Package selector finds UI elements in a `ui dump --tree` hierarchy.
+//
+// Three forms are accepted:
+//
+//	resource-id=com.app:id/send                   attribute conditions
+//	class=Button && text~="^Sub" && nth=2         joined with &&
+//	//LinearLayout/Button[@text='OK']             XPath over the tree
+//	xpath://Button[@text='OK']                    XPath, reporting syntax errors
+//	Send                                          anything else: visible text
+//
+// Matching happens on the client against a dump, so selectors work the same
+// for every command that takes one.
+package selector
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Node is one element of the accessibility tree.
+type Node struct {
+	Index   int
+	Class   string // full class name, e.g. android.widget.Button
+	Package string
+	ID      string // resource id, e.g. com.app:id/send
+	Text    string
+	Desc    string
+	Hint    string
+	Bounds  Rect
+
+	Clickable, Enabled, Focused, Checkable, Checked bool
+	Selected, Scrollable, Editable, Visible         bool
+
+	Parent   *Node // window roots point to a placeholder with Index -1
+	Children []*Node
+}
+
+// Rect is an on-screen rectangle in pixels.
+type Rect struct{ Left, Top, Right, Bottom int }
+
+// ParseRect parses the daemon's "left,top,right,bottom" bounds.
+func ParseRect(s string) (Rect, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return Rect{}, fmt.Errorf("bad bounds %q", s)
+	}
+	var v [4]int
+	for i, p := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(p))
+		if err != nil {
+			return Rect{}, fmt.Errorf("bad bounds %q", s)
+		}
+		v[i] = n
+	}
+	return Rect{v[0], v[1], v[2], v[3]}, nil
+}
+
+// Center returns the middle of the rectangle.
+func (r Rect) Center() (x, y int) { return (r.Left + r.Right) / 2, (r.Top + r.Bottom) / 2 }
+
+// Empty reports whether the rectangle has no area, i.e. is off screen.
+func (r Rect) Empty() bool { return r.Right <= r.Left || r.Bottom <= r.Top }
+
+func (r Rect) String() string { return fmt.Sprintf("[%d,%d][%d,%d]", r.Left, r.Top, r.Right, r.Bottom) }
+
+// ShortClass is the class name without its package, e.g. Button.
+func (n *Node) ShortClass() string { return n.Class[strings.LastIndex(n.Class, ".")+1:] }
+
+// Label is the text a person would use to describe the node.
+func (n *Node) Label() string {
+	switch {
+	case n.Text != "":
+		return n.Text
+	case n.Desc != "":
+		return n.Desc
+	}
+	return n.Hint
+}
+
+// attrNames maps every accepted attribute name to its canonical one.
+var attrNames = map[string]string{
+	"text": "text", "desc": "desc", "content-desc": "desc", "description": "desc",
+	"resource-id": "resource-id", "id": "resource-id", "class": "class", "package": "package",
+	"hint": "hint", "clickable": "clickable", "enabled": "enabled", "focused": "focused",
+	"checkable": "checkable", "checked": "checked", "selected": "selected",
+	"scrollable": "scrollable", "editable": "editable", "visible": "visible",
+}
+
+// Attr returns the named attribute; booleans are "true" or "false".
+func (n *Node) Attr(name string) string {
+	b := strconv.FormatBool
+	switch attrNames[name] {
+	case "text":
+		return n.Text
+	case "desc":
+		return n.Desc
+	case "resource-id":
+		return n.ID
+	case "class":
+		return n.Class
+	case "package":
+		return n.Package
+	case "hint":
+		return n.Hint
+	case "clickable":
+		return b(n.Clickable)
+	case "enabled":
+		return b(n.Enabled)
+	case "focused":
+		return b(n.Focused)
+	case "checkable":
+		return b(n.Checkable)
+	case "checked":
+		return b(n.Checked)
+	case "selected":
+		return b(n.Selected)
+	case "scrollable":
+		return b(n.Scrollable)
+	case "editable":
+		return b(n.Editable)
+	case "visible":
+		return b(n.Visible)
+	}
+	return ""
+}
+
+// equalAttr compares an attribute with a value, letting class names omit
+// their package (Button) and resource ids their package (id/send or send).
+func (n *Node) equalAttr(name, value string) bool {
+	switch attrNames[name] {
+	case "class":
+		if !strings.Contains(value, ".") {
+			return n.ShortClass() == value
+		}
+	case "resource-id":
+		if !strings.Contains(value, ":") && n.ID != "" {
+			return n.ID == value || strings.HasSuffix(n.ID, ":"+value) || strings.HasSuffix(n.ID, "/"+value)
+		}
+	}
+	return n.Attr(name) == value
+}
+
+// Tree is a dumped window. Nodes are in document order.
+type Tree struct {
+	Nodes []*Node
+	root  Node // virtual document node above the window roots
+}
+
+// NewTree links nodes into a tree; parents[i] is the index of the parent of
+// nodes[i] in the slice, or -1 for a root.
+func NewTree(nodes []*Node, parents []int) *Tree {
+	t := &Tree{Nodes: nodes, root: Node{Index: -1}}
+	for i, n := range nodes {
+		p := &t.root
+		if parents[i] >= 0 && parents[i] < len(nodes) {
+			p = nodes[parents[i]]
+		}
+		n.Parent = p
+		p.Children = append(p.Children, n)
+	}
+	return t
+}
+
+// Roots returns the top-level nodes of the window.
+func (t *Tree) Roots() []*Node { return t.root.Children }
+
+// Selector finds nodes in a tree.
+type Selector interface {
+	// Find returns the matching nodes in document order.
+	Find(t *Tree) []*Node
+	String() string
+}
+
+// Text matches nodes whose text or description contains it, ignoring case —
+// the same rule 'psh click <text>' uses on the phone.
+type Text string
+
+func (s Text) Find(t *Tree) []*Node {
+	q := strings.ToLower(string(s))
+	var out []*Node
+	for _, n := range t.Nodes {
+		if strings.Contains(strings.ToLower(n.Text), q) || strings.Contains(strings.ToLower(n.Desc), q) {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+func (s Text) String() string { return string(s) }
+
+var conditionStartRe = regexp.MustCompile(`^([a-z][a-z-]*)\s*(=|!=|~=)`)
+
+// Parse compiles a selector. Input starting with a known attribute and =,
+// != or ~= is a condition list. Input starting with / is XPath if it parses
+// as such and Text otherwise; prefix xpath: to have XPath errors reported
+// instead. Anything else is Text.
+func Parse(s string) (Selector, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, fmt.Errorf("empty selector")
+	}
+	if rest, ok := strings.CutPrefix(s, "xpath:"); ok {
+		return parseXPath(strings.TrimSpace(rest))
+	}
+	if strings.HasPrefix(s, "/") {
+		if x, err := parseXPath(s); err == nil {
+			return x, nil
+		}
+		return Text(s), nil
+	}
+	if m := conditionStartRe.FindStringSubmatch(s); m != nil && (attrNames[m[1]] != "" || m[1] == "nth") {
+		return parseConditions(s)
+	}
+	return Text(s), nil
+}
+
+// ── attribute conditions ─────────────────────────────────────────────────────
+
+type condition struct {
+	attr, op, value string
+	re              *regexp.Regexp
+}
+
+func (c condition) matches(n *Node) bool {
+	switch c.op {
+	case "=":
+		return n.equalAttr(c.attr, c.value)
+	case "!=":
+		return !n.equalAttr(c.attr, c.value)
+	}
+	return c.re.MatchString(n.Attr(c.attr))
+}
+
+type conditions struct {
+	src   string
+	conds []condition
+	nth   int // 1-based; 0 = all matches
+}
+
+func (s *conditions) Find(t *Tree) []*Node {
+	var out []*Node
+	for _, n := range t.Nodes {
+		ok := true
+		for _, c := range s.conds {
+			if !c.matches(n) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			out = append(out, n)
+		}
+	}
+	if s.nth > 0 {
+		if s.nth > len(out) {
+			return nil
+		}
+		return out[s.nth-1 : s.nth]
+	}
+	return out
+}
+
+func (s *conditions) String() string { return s.src }
+
+var boolAttrs = map[string]bool{
+	"clickable": true, "enabled": true, "focused": true, "checkable": true, "checked": true,
+	"selected": true, "scrollable": true, "editable": true, "visible": true,
+}
+
+func parseConditions(src string) (*conditions, error) {
+	sel := &conditions{src: src}
+	sc := &scanner{s: src}
+	for {
+		sc.skipSpace()
+		key := sc.ident()
+		if key == "" {
+			return nil, sc.errorf("expected an attribute name")
+		}
+		sc.skipSpace()
+		op := ""
+		for _, o := range []string{"!=", "~=", "="} {
+			if sc.consume(o) {
+				op = o
+				break
+			}
+		}
+		if op == "" {
+			return nil, sc.errorf("expected =, != or ~= after %q", key)
+		}
+		sc.skipSpace()
+		value, err := sc.value()
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case key == "nth":
+			n, err := strconv.Atoi(value)
+			if op != "=" || err != nil || n < 1 {
+				return nil, fmt.Errorf("selector: nth must be a number from 1, as in nth=2")
+			}
+			sel.nth = n
+		case attrNames[key] == "":
+			return nil, fmt.Errorf("selector: unknown attribute %q (known: %s)", key, knownAttrs())
+		default:
+			c := condition{attr: key, op: op, value: value}
+			if op == "~=" {
+				if c.re, err = regexp.Compile(value); err != nil {
+					return nil, fmt.Errorf("selector: %s~=: %w", key, err)
+				}
+			} else if boolAttrs[attrNames[key]] && value != "true" && value != "false" {
+				return nil, fmt.Errorf("selector: %s must be true or false", key)
+			}
+			sel.conds = append(sel.conds, c)
+		}
+
+		sc.skipSpace()
+		if sc.done() {
+			return sel, nil
+		}
+		if !sc.consume("&&") {
+			return nil, sc.errorf("expected && between conditions")
+		}
+	}
+}
+
+func knownAttrs() string {
+	names := make([]string, 0, len(attrNames))
+	for k := range attrNames {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ") + ", nth"
+}
+
+// ── XPath ────────────────────────────────────────────────────────────────────
+
+// xpath supports the subset that is useful on view hierarchies: / and //
+// steps, *, class names (short or full), .., and predicates with [N],
+// @attr='v', @attr!='v', @attr, contains(), starts-with(), matches() and and.
+type xpath struct {
+	src   string
+	steps []xstep
+}
+
+type xstep struct {
+	descendant bool   // reached with //
+	test       string // *, a class name, .. or .
+	preds      []xpred
+}
+
+// xpred is a positional predicate (pos > 0) or a list of and-ed tests.
+type xpred struct {
+	pos   int
+	terms []xterm
+}
+
+type xterm struct {
+	fn, attr, value string // fn is =, !=, has, contains, starts-with or matches
+	re              *regexp.Regexp
+}
+
+func (x *xpath) Find(t *Tree) []*Node {
+	ctx := []*Node{&t.root}
+	for _, st := range x.steps {
+		seen := map[*Node]bool{}
+		var next []*Node
+		add := func(n *Node) {
+			if !seen[n] {
+				seen[n] = true
+				next = append(next, n)
+			}
+		}
+		for _, c := range ctx {
+			switch st.test {
+			case "..":
+				if c.Parent != nil {
+					add(c.Parent)
+				}
+				continue
+			case ".":
+				add(c)
+				continue
+			}
+			parents := []*Node{c}
+			if st.descendant {
+				parents = descendantsOrSelf(c, nil)
+			}
+			for _, p := range parents {
+				for _, n := range st.filter(p.Children) {
+					add(n)
+				}
+			}
+		}
+		sort.Slice(next, func(i, j int) bool { return next[i].Index < next[j].Index })
+		ctx = next
+	}
+	out := ctx[:0:0]
+	for _, n := range ctx {
+		if n != &t.root {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+func (x *xpath) String() string { return x.src }
+
+func descendantsOrSelf(n *Node, out []*Node) []*Node {
+	out = append(out, n)
+	for _, c := range n.Children {
+		out = descendantsOrSelf(c, out)
+	}
+	return out
+}
+
+// filter applies the node test and predicates to one parent's children, so
+// positions count among siblings as in XPath.
+func (st xstep) filter(children []*Node) []*Node {
+	var cur []*Node
+	for _, n := range children {
+		if st.test == "*" || n.equalAttr("class", st.test) {
+			cur = append(cur, n)
+		}
+	}
+	for _, p := range st.preds {
+		if p.pos > 0 {
+			if p.pos > len(cur) {
+				return nil
+			}
+			cur = cur[p.pos-1 : p.pos]
+			continue
+		}
+		var kept []*Node
+		for _, n := range cur {
+			if p.matches(n) {
+				kept = append(kept, n)
+			}
+		}
+		cur = kept
+	}
+	return cur
+}
+
+func (p xpred) matches(n *Node) bool {
+	for _, t := range p.terms {
+		v := n.Attr(t.attr)
+		var ok bool
+		switch t.fn {
+		case "=":
+			ok = n.equalAttr(t.attr, t.value)
+		case "!=":
+			ok = !n.equalAttr(t.attr, t.value)
+		case "has":
+			ok = v != "" && v != "false"
+		case "contains":
+			ok = strings.Contains(v, t.value)
+		case "starts-with":
+			ok = strings.HasPrefix(v, t.value)
+		case "matches":
+			ok = t.re.MatchString(v)
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func parseXPath(src string) (*xpath, error) {
+	x := &xpath{src: src}
+	sc := &scanner{s: src}
+	for !sc.done() {
+		var st xstep
+		switch {
+		case sc.consume("//"):
+			st.descendant = true
+		case sc.consume("/"):
+		default:
+			return nil, sc.errorf("expected / or //")
+		}
+		switch {
+		case sc.consume(".."):
+			st.test = ".."
+		case sc.consume("."):
+			st.test = "."
+		case sc.consume("*"):
+			st.test = "*"
+		default:
+			if st.test = sc.className(); st.test == "" {
+				return nil, sc.errorf("expected a class name, * or ..")
+			}
+		}
+		for sc.consume("[") {
+			p, err := parsePredicate(sc)
+			if err != nil {
+				return nil, err
+			}
+			st.preds = append(st.preds, p)
+		}
+		x.steps = append(x.steps, st)
+	}
+	if len(x.steps) == 0 {
+		return nil, fmt.Errorf("selector: empty XPath")
+	}
+	return x, nil
+}
+
+func parsePredicate(sc *scanner) (xpred, error) {
+	var p xpred
+	sc.skipSpace()
+	if d := sc.digits(); d != "" {
+		p.pos, _ = strconv.Atoi(d)
+		sc.skipSpace()
+		if p.pos < 1 || !sc.consume("]") {
+			return p, sc.errorf("expected a position from 1 followed by ]")
+		}
+		return p, nil
+	}
+	for {
+		t, err := parseTerm(sc)
+		if err != nil {
+			return p, err
+		}
+		p.terms = append(p.terms, t)
+		sc.skipSpace()
+		if sc.consume("]") {
+			return p, nil
+		}
+		if !sc.consume("and") {
+			return p, sc.errorf("expected 'and' or ]")
+		}
+		sc.skipSpace()
+	}
+}
+
+func parseTerm(sc *scanner) (xterm, error) {
+	var t xterm
+	if sc.consume("@") {
+		t.attr = sc.ident()
+		if attrNames[t.attr] == "" {
+			return t, fmt.Errorf("selector: unknown attribute @%s (known: %s)", t.attr, knownAttrs())
+		}
+		sc.skipSpace()
+		switch {
+		case sc.consume("!="):
+			t.fn = "!="
+		case sc.consume("="):
+			t.fn = "="
+		default:
+			t.fn = "has"
+			return t, nil
+		}
+		sc.skipSpace()
+		v, err := sc.literal()
+		t.value = v
+		return t, err
+	}
+
+	t.fn = sc.ident()
+	switch t.fn {
+	case "contains", "starts-with", "matches":
+	default:
+		return t, sc.errorf("expected @attribute, contains(), starts-with() or matches()")
+	}
+	sc.skipSpace()
+	if !sc.consume("(") || !sc.consume("@") {
+		return t, sc.errorf("expected %s(@attribute, 'value')", t.fn)
+	}
+	t.attr = sc.ident()
+	if attrNames[t.attr] == "" {
+		return t, fmt.Errorf("selector: unknown attribute @%s (known: %s)", t.attr, knownAttrs())
+	}
+	sc.skipSpace()
+	if !sc.consume(",") {
+		return t, sc.errorf("expected , in %s()", t.fn)
+	}
+	sc.skipSpace()
+	v, err := sc.literal()
+	if err != nil {
+		return t, err
+	}
+	t.value = v
+	sc.skipSpace()
+	if !sc.consume(")") {
+		return t, sc.errorf("expected ) to close %s()", t.fn)
+	}
+	if t.fn == "matches" {
+		if t.re, err = regexp.Compile(v); err != nil {
+			return t, fmt.Errorf("selector: matches(): %w", err)
+		}
+	}
+	return t, nil
+}
+
+// ── scanning ─────────────────────────────────────────────────────────────────
+
+type scanner struct {
+	s   string
+	pos int
+}
+
+func (sc *scanner) done() bool { return sc.pos >= len(sc.s) }
+
+func (sc *scanner) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("selector: %s at column %d of %q", fmt.Sprintf(format, args...), sc.pos+1, sc.s)
+}
+
+func (sc *scanner) skipSpace() {
+	for !sc.done() && (sc.s[sc.pos] == ' ' || sc.s[sc.pos] == '\t') {
+		sc.pos++
+	}
+}
+
+func (sc *scanner) consume(tok string) bool {
+	if strings.HasPrefix(sc.s[sc.pos:], tok) {
+		sc.pos += len(tok)
+		return true
+	}
+	return false
+}
+
+func (sc *scanner) take(ok func(c byte) bool) string {
+	start := sc.pos
+	for !sc.done() && ok(sc.s[sc.pos]) {
+		sc.pos++
+	}
+	return sc.s[start:sc.pos]
+}
+
+func isLower(c byte) bool { return c >= 'a' && c <= 'z' }
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+func (sc *scanner) ident() string {
+	return sc.take(func(c byte) bool { return isLower(c) || c == '-' })
+}
+
+func (sc *scanner) digits() string { return sc.take(isDigit) }
+
+func (sc *scanner) className() string {
+	return sc.take(func(c byte) bool {
+		return isLower(c) || isDigit(c) || (c >= 'A' && c <= 'Z') || c == '.' || c == '_' || c == '$'
+	})
+}
+
+// literal reads a '...' or "..." string. A backslash escapes only the quote
+// and itself, so regular expressions can be written as is.
+func (sc *scanner) literal() (string, error) {
+	if sc.done() || (sc.s[sc.pos] != '\'' && sc.s[sc.pos] != '"') {
+		return "", sc.errorf("expected a quoted value")
+	}
+	q := sc.s[sc.pos]
+	sc.pos++
+	var b strings.Builder
+	for !sc.done() {
+		c := sc.s[sc.pos]
+		sc.pos++
+		switch {
+		case c == q:
+			return b.String(), nil
+		case c == '\\' && !sc.done() && (sc.s[sc.pos] == q || sc.s[sc.pos] == '\\'):
+			b.WriteByte(sc.s[sc.pos])
+			sc.pos++
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", sc.errorf("unterminated %c string", q)
+}
+
+// value reads a quoted literal or a bare word running up to && or the end.
+func (sc *scanner) value() (string, error) {
+	if !sc.done() && (sc.s[sc.pos] == '\'' || sc.s[sc.pos] == '"') {
+		return sc.literal()
+	}
+	end := strings.Index(sc.s[sc.pos:], "&&")
+	if end < 0 {
+		end = len(sc.s) - sc.pos
+	}
+	v := strings.TrimSpace(sc.s[sc.pos : sc.pos+end])
+	sc.pos += end
+	return v, nil
+}
@@ -0,0 +1,147 @@
+package selector
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testTree is
+//
+//	0 FrameLayout
+//	  1 LinearLayout  id=com.app:id/row
+//	    2 Button      "OK"      clickable
+//	    3 Button      "Cancel"  clickable
+//	  4 LinearLayout
+//	    5 TextView    "Submit"  desc="Play now"
+//	    6 Button      "OK"
+func testTree() *Tree {
+	n := func(i int, class, text string) *Node {
+		return &Node{Index: i, Class: "android.widget." + class, Package: "com.app", Text: text, Visible: true}
+	}
+	nodes := []*Node{
+		n(0, "FrameLayout", ""),
+		n(1, "LinearLayout", ""),
+		n(2, "Button", "OK"),
+		n(3, "Button", "Cancel"),
+		n(4, "LinearLayout", ""),
+		n(5, "TextView", "Submit"),
+		n(6, "Button", "OK"),
+	}
+	nodes[1].ID = "com.app:id/row"
+	nodes[2].Clickable = true
+	nodes[3].Clickable = true
+	nodes[5].Desc = "Play now"
+	return NewTree(nodes, []int{-1, 0, 1, 1, 0, 4, 4})
+}
+
+func TestParseKind(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string // text, conditions or xpath
+	}{
+		{"Send", "text"},
+		{"Total=5", "text"},
+		{"status~=ok", "text"},
+		{"text=OK", "conditions"},
+		{"resource-id = com.app:id/send", "conditions"},
+		{"nth=2", "conditions"},
+		{"//Button", "xpath"},
+		{"/FrameLayout/*", "xpath"},
+		{"//Button[", "text"},
+		{"/ 2 left", "text"},
+		{"xpath://Button", "xpath"},
+	}
+	for _, tt := range tests {
+		sel, err := Parse(tt.in)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.in, err)
+			continue
+		}
+		var got string
+		switch sel.(type) {
+		case Text:
+			got = "text"
+		case *conditions:
+			got = "conditions"
+		case *xpath:
+			got = "xpath"
+		}
+		if got != tt.want {
+			t.Errorf("Parse(%q) is %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"", "empty selector"},
+		{"text=OK && nth=0", "nth must be a number"},
+		{"nth~=2", "nth must be a number"},
+		{"text=OK && foo=1", "unknown attribute \"foo\""},
+		{"clickable=yes", "must be true or false"},
+		{"text~=\"(\"", "text~="},
+		{"text=\"OK", "unterminated"},
+		{"xpath://Button[", "expected"},
+		{"xpath://Button[@foo='x']", "unknown attribute @foo"},
+		{"xpath://Button[0]", "position from 1"},
+		{"xpath:Button", "expected / or //"},
+	}
+	for _, tt := range tests {
+		sel, err := Parse(tt.in)
+		if err == nil {
+			t.Errorf("Parse(%q) = %v, want an error", tt.in, sel)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Parse(%q) error %q, want it to contain %q", tt.in, err, tt.want)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	tests := []struct {
+		sel  string
+		want []int
+	}{
+		{"ok", []int{2, 6}},
+		{"play", []int{5}},
+		{"text=OK", []int{2, 6}},
+		{"text=OK && nth=2", []int{6}},
+		{"text=OK && nth=3", nil},
+		{`class=Button && text~="^C"`, []int{3}},
+		{"class=android.widget.TextView", []int{5}},
+		{"id=row", []int{1}},
+		{"resource-id=com.app:id/row", []int{1}},
+		{"clickable=true && text!=Cancel", []int{2}},
+		{"//Button", []int{2, 3, 6}},
+		{"//LinearLayout/Button[@text='OK']", []int{2, 6}},
+		{"//LinearLayout/Button[1]", []int{2, 6}},
+		{"//LinearLayout/*[2]", []int{3, 6}},
+		{"/FrameLayout/LinearLayout[2]/Button", []int{6}},
+		{"//Button[@clickable][2]", []int{3}},
+		{"//*[contains(@desc,'Play')]/..", []int{4}},
+		{"//Button/..", []int{1, 4}},
+		{"//Button[starts-with(@text,'Ca') and @clickable='true']", []int{3}},
+		{"//*[matches(@text,'^(OK|Submit)$')]", []int{2, 5, 6}},
+		{"//TextView/.", []int{5}},
+		{"/..", nil},
+	}
+	tree := testTree()
+	for _, tt := range tests {
+		sel, err := Parse(tt.sel)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.sel, err)
+			continue
+		}
+		var got []int
+		for _, n := range sel.Find(tree) {
+			got = append(got, n.Index)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q found %v, want %v", tt.sel, got, tt.want)
+		}
+	}
+}